@@ -0,0 +1,197 @@
+package main
+
+import (
+	"embed"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/pkartner/timeline/game"
+)
+
+const AppName = "timeline"
+
+//go:embed data/*.json data/locale/*.json
+var embeddedData embed.FS
+
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+var (
+	configFile = flag.String("config", "", "config file, defaults to config.json in the user config directory")
+	dataDir = flag.String("data", "", "directory with the base game data, defaults to the first data directory found in the search paths")
+	saveDir = flag.String("save", "", "directory the save games are stored in")
+	locale = flag.String("locale", "", "locale used for display names and descriptions, for example nl")
+	dumpData = flag.Bool("dump-data", false, "print the effective data after applying mods and exit")
+	graphDOT = flag.String("graph-dot", "", "write the value and policy dependency graph as graphviz dot to the file and exit, - for stdout")
+	graphJSON = flag.String("graph-json", "", "write the value and policy dependency graph as json to the file and exit, - for stdout")
+	compact = flag.String("compact", "", "drop the events of deleted branches from the named save and exit")
+	checkSnapshot = flag.String("check-snapshot", "", "compare loading the named save from its snapshot with replaying all its events and exit")
+	exportLog = flag.String("export-log", "", "write the events of the named save as json lines to the -log file and exit")
+	importLog = flag.String("import-log", "", "create the named save from the json lines in the -log file and exit")
+	logFile = flag.String("log", "-", "event log file used by -export-log and -import-log, - for stdout or stdin")
+	profileFile = flag.String("profile", "", "file the profile with achievements is kept in, defaults to profile.json in the user data directory")
+	autoPrune = flag.Bool("auto-prune", false, "archive lost branches once the player leaves them")
+	forbidRewindBranching = flag.Bool("forbid-rewind-branching", false, "refuse policy changes and ended turns while rewound instead of starting a new branch for them")
+	mods stringList
+)
+
+func init() {
+	flag.Var(&mods, "mod", "data directory applied on top of the base data, can be repeated")
+}
+
+type Config struct {
+	DataDir string `json:"data_dir"`
+	SaveDir string `json:"save_dir"`
+	Mods []string `json:"mods"`
+	Locale string `json:"locale"`
+	AutoPrune bool `json:"auto_prune"`
+	ProfileFile string `json:"profile_file"`
+	ForbidRewindBranching bool `json:"forbid_rewind_branching"`
+}
+
+// LoadConfig reads the config file and applies the command line flags on
+// top of it. Paths that are still unset get their defaults.
+func LoadConfig() *Config {
+	config := Config{}
+	fileName := *configFile
+	if fileName == "" {
+		fileName = filepath.Join(UserConfigDir(), "config.json")
+	}
+	data, err := ioutil.ReadFile(fileName)
+	if nil != err && !os.IsNotExist(err) {
+		panic(err)
+	}
+	if nil == err {
+		if err := json.Unmarshal(data, &config); nil != err {
+			panic(fmt.Errorf("Could not parse config file %s: %s", fileName, err))
+		}
+	}
+	if *dataDir != "" {
+		config.DataDir = *dataDir
+	}
+	if *saveDir != "" {
+		config.SaveDir = *saveDir
+	}
+	if *locale != "" {
+		config.Locale = *locale
+	}
+	config.Mods = append(config.Mods, mods...)
+	if *autoPrune {
+		config.AutoPrune = true
+	}
+	if *forbidRewindBranching {
+		config.ForbidRewindBranching = true
+	}
+	if *profileFile != "" {
+		config.ProfileFile = *profileFile
+	}
+	if config.SaveDir == "" {
+		config.SaveDir = DefaultSaveDir()
+	}
+	if config.ProfileFile == "" {
+		config.ProfileFile = filepath.Join(UserDataDir(), "profile.json")
+	}
+
+	return &config
+}
+
+// LegacySaveDir is where saves were kept before the save directory was
+// configurable, relative to the working directory.
+const LegacySaveDir = "save"
+
+// DefaultSaveDir keeps using the legacy save directory when it exists so
+// existing saves stay listed, new players get the user data directory.
+func DefaultSaveDir() string {
+	if info, err := os.Stat(LegacySaveDir); nil == err && info.IsDir() {
+		return LegacySaveDir
+	}
+	return filepath.Join(UserDataDir(), "save")
+}
+
+// DataRoots returns the base data followed by the configured mods. Without
+// a configured data directory the search paths are tried, falling back to
+// the data embedded in the binary.
+func (c *Config) DataRoots() []game.DataRoot {
+	roots := []game.DataRoot{}
+	if c.DataDir != "" {
+		roots = append(roots, game.DataRoot{Name: c.DataDir, FS: os.DirFS(c.DataDir)})
+	} else {
+		roots = append(roots, DefaultDataRoot())
+	}
+	for _, v := range c.Mods {
+		roots = append(roots, game.DataRoot{Name: v, FS: os.DirFS(v)})
+	}
+	return roots
+}
+
+func DefaultDataRoot() game.DataRoot {
+	for _, v := range DataSearchPaths() {
+		if _, err := os.Stat(filepath.Join(v, game.ScenarioFile)); nil == err {
+			return game.DataRoot{Name: v, FS: os.DirFS(v)}
+		}
+	}
+	embedded, err := fs.Sub(embeddedData, "data")
+	if nil != err {
+		panic(err)
+	}
+	return game.DataRoot{Name: "embedded", FS: embedded}
+}
+
+// DataSearchPaths lists the directories searched for the base data, the
+// user data directory first.
+func DataSearchPaths() []string {
+	paths := []string{filepath.Join(UserDataDir(), "data")}
+	if runtime.GOOS == "linux" {
+		dataDirs := os.Getenv("XDG_DATA_DIRS")
+		if dataDirs == "" {
+			dataDirs = "/usr/local/share:/usr/share"
+		}
+		for _, v := range filepath.SplitList(dataDirs) {
+			paths = append(paths, filepath.Join(v, AppName, "data"))
+		}
+	}
+	if executable, err := os.Executable(); nil == err {
+		paths = append(paths, filepath.Join(filepath.Dir(executable), "data"))
+	}
+	return paths
+}
+
+func UserConfigDir() string {
+	dir, err := os.UserConfigDir()
+	if nil != err {
+		return "."
+	}
+	return filepath.Join(dir, AppName)
+}
+
+// UserDataDir follows XDG_DATA_HOME on linux and uses the user config
+// directory on other systems.
+func UserDataDir() string {
+	if runtime.GOOS != "linux" {
+		return UserConfigDir()
+	}
+	dir := os.Getenv("XDG_DATA_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if nil != err {
+			return "."
+		}
+		dir = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dir, AppName)
+}
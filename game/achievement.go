@@ -0,0 +1,186 @@
+package game
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/pkartner/event"
+)
+
+const AchievementsProjectionName = "achievements"
+
+// Achievement is unlocked after an event of the type once the save has seen
+// Count of them and the branch of the event meets the other requirements.
+type Achievement struct {
+	Name string `json:"name"`
+	Description string `json:"description"`
+	Event event.EventType `json:"event"`
+	Count uint64 `json:"count"`
+	// Outcome is won, lost or playing when the branch has to be in that
+	// state.
+	Outcome string `json:"outcome"`
+	Conditions []GameEndCondition `json:"conditions"`
+	// NeverPolicies must not have been active during any turn of the branch.
+	NeverPolicies []string `json:"never_policies"`
+}
+
+type Achievements struct {
+	Achievements map[string]Achievement `json:"achievements"`
+}
+
+// AchievementIDs returns the ids of the achievements sorted.
+func (d *Data) AchievementIDs() []string {
+	ids := []string{}
+	for k := range d.Achievements.Achievements {
+		ids = append(ids, k)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func (d *Data) AchievementName(id string) string {
+	name := d.Achievements.Achievements[id].Name
+	if name == "" {
+		name = id
+	}
+	return d.Text("achievement."+id+".name", name)
+}
+
+func (d *Data) AchievementDescription(id string) string {
+	return d.Text("achievement."+id+".description", d.Achievements.Achievements[id].Description)
+}
+
+// conditionHolds is EvaluateGameEndCondition for conditions written by
+// hand, unknown values don't hold.
+func conditionHolds(turn uint64, condition *GameEndCondition, values ValueMap) bool {
+	if _, ok := values[condition.Name]; !ok && condition.Name != "turn" {
+		return false
+	}
+	return EvaluateGameEndCondition(turn, condition, values)
+}
+
+// Reached returns if the branch meets the requirements besides the event
+// count.
+func (a *Achievement) Reached(store *BranchStore) bool {
+	if a.Outcome != "" && a.Outcome != OutcomeString(store.GameOver) {
+		return false
+	}
+	for k := range a.Conditions {
+		if !conditionHolds(store.Turn, &a.Conditions[k], store.Values) {
+			return false
+		}
+	}
+	for _, state := range store.States() {
+		for _, v := range state.ActivePolicies {
+			for _, v2 := range a.NeverPolicies {
+				if v == v2 {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+// AchievementProjection unlocks the achievements of the data as the events
+// of a save come in. OnUnlock is called for every unlock, also when the
+// projection is rebuilt or restored from a snapshot, but never for saves that
+// were modified outside the game.
+type AchievementProjection struct {
+	Data *Data
+	Counts map[event.EventType]uint64
+	Unlocked map[string]uint64
+	OnUnlock func(id string, time uint64)
+}
+
+func NewAchievementProjection(gameData *Data, onUnlock func(string, uint64)) *AchievementProjection {
+	p := &AchievementProjection{
+		Data: gameData,
+		OnUnlock: onUnlock,
+	}
+	p.Reset()
+	return p
+}
+
+func (p *AchievementProjection) Name() string {
+	return AchievementsProjectionName
+}
+
+func (p *AchievementProjection) Reset() {
+	p.Counts = map[event.EventType]uint64{}
+	p.Unlocked = map[string]uint64{}
+}
+
+func (p *AchievementProjection) Apply(g *Instance, e event.Event) {
+	// Creating the root branch isn't creating a branch to the player
+	if _, ok := e.(*event.NewBranchEvent); !ok || len(g.GetTimeLineStore().Branches) > 1 {
+		p.Counts[e.Type()]++
+	}
+
+	var store *BranchStore
+	branchID := g.GetGameStore().CurrentBranch
+	if branches := eventBranches(e); len(branches) > 0 {
+		branchID = branches[0]
+	}
+	if branchStore, err := g.branchStore(branchID); nil == err {
+		store = branchStore
+	}
+
+	for _, id := range p.Data.AchievementIDs() {
+		if _, ok := p.Unlocked[id]; ok {
+			continue
+		}
+		achievement := p.Data.Achievements.Achievements[id]
+		if achievement.Event != "" && achievement.Event != e.Type() {
+			continue
+		}
+		if p.Counts[e.Type()] < achievement.Count {
+			continue
+		}
+		if nil == store || !achievement.Reached(store) {
+			continue
+		}
+		p.Unlocked[id] = e.Time()
+		p.unlock(g, id, e.Time())
+	}
+}
+
+func (p *AchievementProjection) unlock(g *Instance, id string, time uint64) {
+	if nil == p.OnUnlock || (nil != g.Chain && g.Chain.Modified) {
+		return
+	}
+	p.OnUnlock(id, time)
+}
+
+// Restored hands the achievements unlocked before the snapshot to OnUnlock.
+func (p *AchievementProjection) Restored(g *Instance) {
+	for _, id := range p.Data.AchievementIDs() {
+		if time, ok := p.Unlocked[id]; ok {
+			p.unlock(g, id, time)
+		}
+	}
+}
+
+type achievementState struct {
+	Counts map[event.EventType]uint64
+	Unlocked map[string]uint64
+}
+
+func (p *AchievementProjection) MarshalState() ([]byte, error) {
+	return json.Marshal(achievementState{p.Counts, p.Unlocked})
+}
+
+func (p *AchievementProjection) UnmarshalState(data []byte) error {
+	state := achievementState{}
+	if err := json.Unmarshal(data, &state); nil != err {
+		return err
+	}
+	p.Reset()
+	for k, v := range state.Counts {
+		p.Counts[k] = v
+	}
+	for k, v := range state.Unlocked {
+		p.Unlocked[k] = v
+	}
+	return nil
+}
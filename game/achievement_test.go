@@ -0,0 +1,60 @@
+package game
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestAchievementsReachProfile(t *testing.T) {
+	gameData := testData(t)
+	gameData.Achievements.Achievements = map[string]Achievement{
+		"first_turn": {Event: NextTurnEventType, Count: 1},
+	}
+	tests := []struct {
+		name string
+		modified bool
+		want map[string]uint64
+	}{
+		{"restored from snapshot", false, nil},
+		{"modified save", true, map[string]uint64{}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fileName := filepath.Join(t.TempDir(), "save")
+			unlocked := map[string]uint64{}
+			onUnlock := func(id string, time uint64) {
+				unlocked[id] = time
+			}
+			g := newTestGame(t, fileName, gameData)
+			g.SnapshotInterval = 5
+			g.projections = []Projection{NewAchievementProjection(gameData, onUnlock)}
+			playTestGame(t, g)
+			if _, ok := unlocked["first_turn"]; !ok {
+				t.Fatal("first_turn wasn't unlocked while playing")
+			}
+			if test.modified {
+				must(t, g.Chain.MarkModified())
+			}
+			must(t, g.Close())
+
+			want := test.want
+			if nil == want {
+				want = unlocked
+			}
+			restored := map[string]uint64{}
+			g, err := NewGame(fileName, gameData, NewAchievementProjection(gameData, func(id string, time uint64) {
+				restored[id] = time
+			}))
+			must(t, err)
+			defer g.Close()
+			if len(restored) != len(want) {
+				t.Fatalf("got %v, want %v", restored, want)
+			}
+			for k, v := range want {
+				if restored[k] != v {
+					t.Errorf("%s unlocked at %d, want %d", k, restored[k], v)
+				}
+			}
+		})
+	}
+}
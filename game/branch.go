@@ -0,0 +1,218 @@
+package game
+
+import (
+	"github.com/pkartner/event"
+)
+
+// NewBranch creates the event that starts a new branch from the current,
+// possibly rewinded, turn of the current branch.
+func (g *Instance) NewBranch() *event.NewBranchEvent {
+	currentBranchID := g.GetGameStore().CurrentBranch
+	store := g.GetRewindedStore()
+	branchStore := g.GetRewindedBranchStore()
+
+	lastEventID := event.ID{}
+	if store.LastEvent != nil {
+		lastEventID = store.LastEvent.ID()
+	}
+
+	return event.NewBranch(branchStore.Turn, currentBranchID, lastEventID, g.Clock.Now(), g.IDs.NextIDPart())
+}
+
+// Windback creates the event that moves the current branch to the turn.
+func (g *Instance) Windback(turn uint64) *event.WindbackEvent {
+	return event.Windback(turn, g.Clock.Now(), g.IDs.NextIDPart())
+}
+
+// SplitBranch starts a new branch at the current turn after paying for it.
+func (g *Instance) SplitBranch() (*event.NewBranchEvent, error) {
+	cost := g.BranchCost()
+	if err := g.checkEnergy(cost); nil != err {
+		return nil, err
+	}
+	newBranchEvent := g.NewBranch()
+	g.Dispatch(newBranchEvent)
+	g.spendEnergy(cost, "branch")
+	return newBranchEvent, nil
+}
+
+// BranchOff starts a new branch from the current turn and switches to it.
+func (g *Instance) BranchOff() (event.ID, error) {
+	turn := g.GetRewindedBranchStore().Turn
+	newBranchEvent, err := g.SplitBranch()
+	if nil != err {
+		return event.ZeroID(), err
+	}
+	if err := g.Execute(g.SetBranch(newBranchEvent.NewBranchID)); nil != err {
+		return event.ZeroID(), err
+	}
+	g.Dispatch(g.Windback(turn))
+	return newBranchEvent.NewBranchID, nil
+}
+
+// branchIfRewound moves a change made while the current branch is rewound
+// onto a new branch split off at the rewound turn, so the future of the
+// branch stays as it was. The change is validated against the rewound turn
+// first, an invalid change leaves no branch behind.
+func (g *Instance) branchIfRewound(validate func(branchID event.ID, store *BranchStore) error) error {
+	gameStore := g.GetGameStore()
+	if !gameStore.Rewind {
+		return nil
+	}
+	if g.ForbidRewindBranching {
+		return &RewoundError{g.CurrentNode()}
+	}
+	if err := validate(gameStore.CurrentBranch, g.GetRewindedBranchStore()); nil != err {
+		return err
+	}
+	_, err := g.BranchOff()
+	return err
+}
+
+// ChangePolicy toggles the policy on the current branch, on a new branch
+// when the current one is rewound.
+func (g *Instance) ChangePolicy(policy string) error {
+	err := g.branchIfRewound(func(branchID event.ID, store *BranchStore) error {
+		_, active := store.ActivePolicies[policy]
+		return g.validatePolicyState(policy, !active, branchID, store)
+	})
+	if nil != err {
+		return err
+	}
+	return g.Execute(g.SetPolicy(policy))
+}
+
+// EndTurn ends the turn on the current branch, on a new branch when the
+// current one is rewound, and dispatches what follows from it.
+func (g *Instance) EndTurn() error {
+	if err := g.branchIfRewound(g.validateNextTurn); nil != err {
+		return err
+	}
+	if err := g.Execute(g.NextTurn()); nil != err {
+		return err
+	}
+	g.DispatchStoryBeats()
+	g.DispatchDiscoveries()
+	g.RegenerateEnergy()
+	g.DispatchTimelineOutcome()
+	return nil
+}
+
+// CurrentNode returns the turn the player is looking at.
+func (g *Instance) CurrentNode() Node {
+	return Node{g.GetGameStore().CurrentBranch, g.GetRewindedBranchStore().Turn}
+}
+
+// JumpTo switches to the branch of the node and winds back to its turn.
+// Winding back costs temporal energy for the turns between the turn shown
+// on the branch and the node.
+func (g *Instance) JumpTo(node Node) error {
+	if err := g.validateNode(node); nil != err {
+		return err
+	}
+	store, err := g.branchStore(node.Branch)
+	if nil != err {
+		return err
+	}
+	from := store.Turn
+	if node.Branch == g.GetGameStore().CurrentBranch {
+		from = g.GetRewindedBranchStore().Turn
+	}
+	cost := g.WindbackCost(from, node.Turn)
+	if err := g.checkEnergy(cost); nil != err {
+		return err
+	}
+	if err := g.Execute(g.SetBranch(node.Branch)); nil != err {
+		return err
+	}
+	g.Dispatch(g.Windback(node.Turn))
+	g.spendEnergy(cost, "windback")
+	// Spending the energy can leave no way back for a lost timeline
+	g.DispatchTimelineOutcome()
+	return nil
+}
+
+// NextBookmark returns the bookmark after the current node in the order the
+// bookmarks were made, false when there are none.
+func (g *Instance) NextBookmark() (Node, bool) {
+	bookmarks := g.GetGameStore().Bookmarks
+	if len(bookmarks) == 0 {
+		return Node{}, false
+	}
+	current := g.CurrentNode()
+	for k, v := range bookmarks {
+		if v == current {
+			return bookmarks[(k+1)%len(bookmarks)], true
+		}
+	}
+	return bookmarks[0], true
+}
+
+// TurnNote returns the note of the turn as seen from the branch, turns from
+// before the branch split off are looked up on its ancestors.
+func (g *Instance) TurnNote(branchID event.ID, turn uint64) string {
+	path, forks, err := branchPath(g.GetTimeLineStore(), branchID)
+	if nil != err {
+		return ""
+	}
+	notes := g.GetGameStore().TurnNotes
+	for k, v := range path {
+		if note, ok := notes[Node{v, turn}]; ok {
+			return note
+		}
+		if turn > forks[k] {
+			break
+		}
+	}
+	return ""
+}
+
+// BranchDescendants returns the branch followed by every branch split off
+// from it, directly or through other branches.
+func BranchDescendants(timeStore *event.TimelineStore, branchID event.ID) []event.ID {
+	descendants := []event.ID{branchID}
+	for i := 0; i < len(descendants); i++ {
+		for _, v := range timeStore.Branches {
+			if v.PrevBranch == descendants[i] {
+				descendants = append(descendants, v.BranchID)
+			}
+		}
+	}
+	return descendants
+}
+
+// PruneLostBranches archives the lost branches the player left behind,
+// branches are only pruned once all branches split off from them are.
+// It returns the branches that were archived.
+func (g *Instance) PruneLostBranches() []event.ID {
+	timeStore := g.GetTimeLineStore()
+	gameStore := g.GetGameStore()
+	pruned := []event.ID{}
+	// Children come after their parents, going backwards prunes the
+	// children first.
+	for i := len(timeStore.Branches)-1; i >= 0; i-- {
+		branch := timeStore.Branches[i]
+		if gameStore.Hidden(branch.BranchID) {
+			continue
+		}
+		store := GetBranchStore(&timeStore.Stores[branch.StoreID])
+		if store.GameOver != GameLost {
+			continue
+		}
+		visibleChild := false
+		for _, v := range BranchDescendants(timeStore, branch.BranchID)[1:] {
+			if !gameStore.Hidden(v) {
+				visibleChild = true
+				break
+			}
+		}
+		if visibleChild {
+			continue
+		}
+		if err := g.Execute(g.ArchiveBranch(branch.BranchID, true)); nil != err {
+			continue
+		}
+		pruned = append(pruned, branch.BranchID)
+	}
+	return pruned
+}
@@ -0,0 +1,123 @@
+package game
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestInvalidActionWhileRewoundLeavesNoBranch(t *testing.T) {
+	g := newTestGame(t, filepath.Join(t.TempDir(), "save"), testData(t))
+	defer g.Close()
+	root := g.GetGameStore().CurrentBranch
+	for i := 0; i < 4; i++ {
+		must(t, g.EndTurn())
+	}
+	// Rest is locked on until turn 3
+	must(t, g.JumpTo(Node{root, 2}))
+	tests := []struct {
+		name string
+		action func() error
+	}{
+		{"locked policy", func() error { return g.ChangePolicy("rest") }},
+		{"unknown policy", func() error { return g.ChangePolicy("gold") }},
+		{"timeline over", func() error {
+			g.GetGameStore().Timeline = GameLost
+			defer func() { g.GetGameStore().Timeline = 0 }()
+			return g.EndTurn()
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			branches := len(g.GetTimeLineStore().Branches)
+			energy := g.GetGameStore().TemporalEnergy
+			if err := test.action(); nil == err {
+				t.Fatal("the action was taken")
+			}
+			if len(g.GetTimeLineStore().Branches) != branches {
+				t.Errorf("got %d branches, want %d", len(g.GetTimeLineStore().Branches), branches)
+			}
+			if g.GetGameStore().TemporalEnergy != energy {
+				t.Errorf("temporal energy is %v, want %v", g.GetGameStore().TemporalEnergy, energy)
+			}
+			if !g.GetGameStore().Rewind || g.GetGameStore().CurrentBranch != root {
+				t.Error("the player left the rewound turn")
+			}
+		})
+	}
+
+	must(t, g.JumpTo(Node{root, 3}))
+	energy := g.GetGameStore().TemporalEnergy
+	must(t, g.ChangePolicy("collect food"))
+	if len(g.GetTimeLineStore().Branches) != 2 {
+		t.Errorf("got %d branches after a valid action, want 2", len(g.GetTimeLineStore().Branches))
+	}
+	if g.GetGameStore().TemporalEnergy >= energy {
+		t.Error("branching off was free")
+	}
+}
+
+func TestTemporalEnergyCosts(t *testing.T) {
+	gameData := testData(t)
+	gameData.Scenario.TemporalEnergy = &TemporalEnergy{
+		Start: 4,
+		Max: 5,
+		WindbackCost: 1,
+		WindbackCostPerTurn: 1,
+		BranchCost: 2,
+		BranchCostPerTurn: 0.5,
+		Regen: []EnergyRegen{
+			{Amount: 1},
+			{Amount: 10, Condition: &GameEndCondition{Name: "turn", Sign: "+", Value: 100}},
+		},
+	}
+	g := newTestGame(t, filepath.Join(t.TempDir(), "save"), gameData)
+	defer g.Close()
+	root := g.GetGameStore().CurrentBranch
+	energy := func() float64 {
+		return g.GetGameStore().TemporalEnergy
+	}
+	tests := []struct {
+		name string
+		action func() error
+		fails bool
+		energy float64
+		branches int
+		node Node
+	}{
+		{"regen", g.EndTurn, false, 5, 1, Node{root, 1}},
+		{"regen up to max", g.EndTurn, false, 5, 1, Node{root, 2}},
+		{"turn", g.EndTurn, false, 5, 1, Node{root, 3}},
+		{"wind back", func() error { return g.JumpTo(Node{root, 1}) }, false, 2, 1, Node{root, 1}},
+		{"branch off too far back", g.EndTurn, true, 2, 1, Node{root, 1}},
+		{"move forward", func() error { return g.JumpTo(Node{root, 3}) }, false, 2, 1, Node{root, 3}},
+		{"split", func() error {
+			_, err := g.SplitBranch()
+			return err
+		}, false, 0, 2, Node{root, 3}},
+		{"split without energy", func() error {
+			_, err := g.SplitBranch()
+			return err
+		}, true, 0, 2, Node{root, 3}},
+		{"wind back without energy", func() error { return g.JumpTo(Node{root, 2}) }, true, 0, 2, Node{root, 3}},
+		{"turn after split", g.EndTurn, false, 1, 2, Node{root, 4}},
+	}
+	for _, test := range tests {
+		err := test.action()
+		if test.fails {
+			if _, ok := err.(*TemporalEnergyError); !ok {
+				t.Fatalf("%s: got error %v, want not enough temporal energy", test.name, err)
+			}
+		} else if nil != err {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+		if energy() != test.energy {
+			t.Errorf("%s: got energy %v, want %v", test.name, energy(), test.energy)
+		}
+		if len(g.GetTimeLineStore().Branches) != test.branches {
+			t.Errorf("%s: got %d branches, want %d", test.name, len(g.GetTimeLineStore().Branches), test.branches)
+		}
+		if g.CurrentNode() != test.node {
+			t.Errorf("%s: at %v, want %v", test.name, g.CurrentNode(), test.node)
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package game
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/boltdb/bolt"
+	"github.com/pkartner/event"
+)
+
+var chainBucket = []byte("event_chain")
+var chainHeadKey = []byte("head")
+var chainModifiedKey = []byte("modified")
+var chainStartKey = []byte("start")
+
+// ChainedEvent is an event that carries the hash of the events before it.
+type ChainedEvent interface {
+	event.Event
+	ChainHash() string
+	SetChainHash(hash string)
+}
+
+func (s *EventSchema) ChainHash() string {
+	return s.PrevHash
+}
+
+func (s *EventSchema) SetChainHash(hash string) {
+	s.PrevHash = hash
+}
+
+// chainHash returns the hash of the chain after the event, the payload is
+// hashed as it is stored.
+func chainHash(prev string, stored storedEvent) string {
+	hash := sha256.New()
+	hash.Write([]byte(prev))
+	hash.Write([]byte(stored.Type))
+	hash.Write(stored.Payload)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// storedChainHash reads the hash of the chain before the event from its
+// payload, events that aren't game events have none.
+func storedChainHash(stored storedEvent) string {
+	schema := EventSchema{}
+	json.Unmarshal(stored.Payload, &schema)
+	return schema.PrevHash
+}
+
+// ChainedEventStore keeps the events of a save and hashes every event
+// together with the hash of the events before it. Game events carry the hash
+// of the chain before them, the hash after the last event is kept in the
+// save, so editing, removing or reordering events breaks the chain. Events
+// are upcast to the current schema version when they are read back.
+type ChainedEventStore struct {
+	DB *bolt.DB
+	// Modified is set once a restore found the chain broken, the save stays
+	// marked as modified from then on.
+	Modified bool
+	head string
+	// start is the sequence number of the first event that has to carry a
+	// hash, events before it were stored before the chain existed. Saves
+	// that don't record it have hashes from their first event on.
+	start uint64
+	// heads holds the head of the chain after every number of events, it
+	// is filled by a full restore.
+	heads []string
+}
+
+// NewChainedEventStore opens the events of the save, the chain continues
+// from the head kept in the save.
+func NewChainedEventStore(db *bolt.DB) *ChainedEventStore {
+	s := &ChainedEventStore{
+		DB: db,
+		start: 1,
+	}
+	db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(chainBucket)
+		if nil == bucket {
+			return nil
+		}
+		s.head = string(bucket.Get(chainHeadKey))
+		s.Modified = nil != bucket.Get(chainModifiedKey)
+		if start := bucket.Get(chainStartKey); nil != start {
+			s.start = binary.BigEndian.Uint64(start)
+		}
+		return nil
+	})
+	return s
+}
+
+// MigrateLegacy copies the events of a save written by the bolt store of the
+// event library, once, so they are kept as JSON from then on. The library
+// decodes them into the current event structs, they are stored as those. The
+// chain starts at the first copied event with a hash, or after the copied
+// events, new saves start it at their first event.
+func (s *ChainedEventStore) MigrateLegacy(legacy event.EventStore) error {
+	if s.Migrated() {
+		return nil
+	}
+	events := []storedEvent{}
+	err := legacy.Restore(^uint64(0), func(e event.Event) error {
+		payload, err := json.Marshal(e)
+		if nil != err {
+			return err
+		}
+		events = append(events, storedEvent{e.Type(), payload})
+		return nil
+	})
+	if nil != err {
+		return err
+	}
+	start := uint64(len(events)+1)
+	for k, v := range events {
+		if storedChainHash(v) != "" {
+			start = uint64(k+1)
+			break
+		}
+	}
+	err = s.DB.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(eventBucket); nil != err {
+			return err
+		}
+		for _, v := range events {
+			if err := putEvent(tx, v); nil != err {
+				return err
+			}
+		}
+		bucket, err := tx.CreateBucketIfNotExists(chainBucket)
+		if nil != err {
+			return err
+		}
+		return bucket.Put(chainStartKey, eventKey(start))
+	})
+	if nil != err {
+		return err
+	}
+	s.start = start
+	return nil
+}
+
+// Migrated returns if the events of the save are kept by the store, saves
+// written by the bolt store of the event library aren't until they are
+// migrated.
+func (s *ChainedEventStore) Migrated() bool {
+	migrated := false
+	s.DB.View(func(tx *bolt.Tx) error {
+		migrated = nil != tx.Bucket(eventBucket)
+		return nil
+	})
+	return migrated
+}
+
+// Add stores the event and the new head of the chain in one transaction.
+func (s *ChainedEventStore) Add(e event.Event) error {
+	if chained, ok := e.(ChainedEvent); ok {
+		chained.SetChainHash(s.head)
+	}
+	payload, err := json.Marshal(e)
+	if nil != err {
+		return err
+	}
+	stored := storedEvent{e.Type(), payload}
+	head := chainHash(s.head, stored)
+	err = s.DB.Update(func(tx *bolt.Tx) error {
+		if err := putEvent(tx, stored); nil != err {
+			return err
+		}
+		bucket, err := tx.CreateBucketIfNotExists(chainBucket)
+		if nil != err {
+			return err
+		}
+		return bucket.Put(chainHeadKey, []byte(head))
+	})
+	if nil != err {
+		return err
+	}
+	s.head = head
+	if nil != s.heads {
+		s.heads = append(s.heads, head)
+	}
+	return nil
+}
+
+// HeadAfter returns the head of the chain after the number of events, it is
+// only known after a full restore.
+func (s *ChainedEventStore) HeadAfter(count uint64) (string, bool) {
+	if count >= uint64(len(s.heads)) {
+		return "", false
+	}
+	return s.heads[count], true
+}
+
+// Restore hands out the events up to the time. The chain is verified when
+// every event is read back, a broken chain marks the save as modified but
+// the events are still handed out.
+func (s *ChainedEventStore) Restore(time uint64, handleFunc event.ReadEventHandleFunc) error {
+	full := time == ^uint64(0)
+	running := ""
+	count := 0
+	heads := []string{running}
+	broken := false
+	err := readEvents(s.DB, func(sequence uint64, stored storedEvent) error {
+		count++
+		if sequence >= s.start && chainedType(stored.Type) && storedChainHash(stored) != running {
+			broken = true
+		}
+		running = chainHash(running, stored)
+		heads = append(heads, running)
+		e, err := DecodeEvent(stored.Type, stored.Payload)
+		if nil != err {
+			return err
+		}
+		if e.Time() > time {
+			return nil
+		}
+		return handleFunc(e)
+	})
+	if nil != err || !full {
+		return err
+	}
+	if count >= int(s.start) && s.head != running {
+		broken = true
+	}
+	s.head = running
+	s.heads = heads
+	if broken && !s.Modified {
+		fmt.Println("The event chain of the save is broken, marking it as modified")
+		return s.MarkModified()
+	}
+	return nil
+}
+
+// chainedType returns if events of the type carry a chain hash.
+func chainedType(eventType event.EventType) bool {
+	prototype, ok := eventPrototypes[eventType]
+	if !ok {
+		return false
+	}
+	_, ok = prototype().(ChainedEvent)
+	return ok
+}
+
+// MarkModified marks the save as modified for good, a save opened read only
+// is only marked until it is closed.
+func (s *ChainedEventStore) MarkModified() error {
+	s.Modified = true
+	if s.DB.IsReadOnly() {
+		return nil
+	}
+	return s.DB.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(chainBucket)
+		if nil != err {
+			return err
+		}
+		return bucket.Put(chainModifiedKey, []byte{1})
+	})
+}
@@ -0,0 +1,159 @@
+package game
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/boltdb/bolt"
+	"github.com/pkartner/event"
+)
+
+// emptyLegacyStore is the bolt store of the event library for a save that
+// never used it.
+type emptyLegacyStore struct{}
+
+func (emptyLegacyStore) Add(e event.Event) error {
+	return nil
+}
+
+func (emptyLegacyStore) Restore(time uint64, handleFunc event.ReadEventHandleFunc) error {
+	return nil
+}
+
+// writeTestChain stores a few events in a new save the way a game does and
+// returns the file name.
+func writeTestChain(t *testing.T) string {
+	t.Helper()
+	fileName := filepath.Join(t.TempDir(), "chain.db")
+	db, err := bolt.Open(fileName, 0600, nil)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	store := NewChainedEventStore(db)
+	must(t, store.MigrateLegacy(emptyLegacyStore{}))
+	branch := testID(100)
+	must(t, store.Add(testSetPolicy(1, branch, 0, "rest", true)))
+	must(t, store.Add(testNextTurn(2, branch, 1)))
+	must(t, store.Add(testSetPolicy(3, branch, 1, "collect food", true)))
+	return fileName
+}
+
+// tamperTestChain edits the save outside of the game.
+func tamperTestChain(t *testing.T, fileName string, edit func(tx *bolt.Tx) error) {
+	t.Helper()
+	db, err := bolt.Open(fileName, 0600, nil)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	must(t, db.Update(edit))
+}
+
+// editStoredEvents rewrites the payload fields of the stored events for which
+// edit returns true.
+func editStoredEvents(edit func(sequence int, fields map[string]json.RawMessage) bool) func(tx *bolt.Tx) error {
+	return func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(eventBucket)
+		updates := map[string][]byte{}
+		sequence := 0
+		err := bucket.ForEach(func(k, v []byte) error {
+			sequence++
+			stored := storedEvent{}
+			if err := json.Unmarshal(v, &stored); nil != err {
+				return err
+			}
+			fields := map[string]json.RawMessage{}
+			if err := json.Unmarshal(stored.Payload, &fields); nil != err {
+				return err
+			}
+			if !edit(sequence, fields) {
+				return nil
+			}
+			payload, err := json.Marshal(fields)
+			if nil != err {
+				return err
+			}
+			stored.Payload = payload
+			data, err := json.Marshal(stored)
+			if nil != err {
+				return err
+			}
+			updates[string(k)] = data
+			return nil
+		})
+		if nil != err {
+			return err
+		}
+		for k, v := range updates {
+			if err := bucket.Put([]byte(k), v); nil != err {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func TestChainDetectsModifiedSaves(t *testing.T) {
+	clearHash := func(fields map[string]json.RawMessage) bool {
+		fields["PrevHash"] = json.RawMessage(`""`)
+		return true
+	}
+	tests := []struct {
+		name string
+		tamper func(tx *bolt.Tx) error
+		modified bool
+	}{
+		{"unchanged", nil, false},
+		{"edited payload", editStoredEvents(func(sequence int, fields map[string]json.RawMessage) bool {
+			if sequence != 3 {
+				return false
+			}
+			fields["Policy"] = json.RawMessage(`"build shelter"`)
+			return true
+		}), true},
+		{"cleared hash", editStoredEvents(func(sequence int, fields map[string]json.RawMessage) bool {
+			return sequence == 2 && clearHash(fields)
+		}), true},
+		{"cleared all hashes", editStoredEvents(func(sequence int, fields map[string]json.RawMessage) bool {
+			return clearHash(fields)
+		}), true},
+		{"cleared all hashes and chain", func(tx *bolt.Tx) error {
+			if err := editStoredEvents(func(sequence int, fields map[string]json.RawMessage) bool {
+				return clearHash(fields)
+			})(tx); nil != err {
+				return err
+			}
+			return tx.DeleteBucket(chainBucket)
+		}, true},
+		{"removed last event", func(tx *bolt.Tx) error {
+			return tx.Bucket(eventBucket).Delete(eventKey(3))
+		}, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fileName := writeTestChain(t)
+			if nil != test.tamper {
+				tamperTestChain(t, fileName, test.tamper)
+			}
+			db, err := bolt.Open(fileName, 0600, nil)
+			if nil != err {
+				t.Fatal(err)
+			}
+			defer db.Close()
+			store := NewChainedEventStore(db)
+			count := 0
+			must(t, store.Restore(^uint64(0), func(e event.Event) error {
+				count++
+				return nil
+			}))
+			if store.Modified != test.modified {
+				t.Errorf("modified is %t, want %t", store.Modified, test.modified)
+			}
+			if !test.modified && count != 3 {
+				t.Errorf("got %d events, want 3", count)
+			}
+		})
+	}
+}
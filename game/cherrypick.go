@@ -0,0 +1,180 @@
+package game
+
+import (
+	"fmt"
+
+	"github.com/pkartner/event"
+)
+
+// CherryPickStep is a decision taken on the source branch, Turn is the turn
+// the decision was made in.
+type CherryPickStep struct {
+	Turn uint64
+	Type event.EventType
+	Policy string
+	State bool
+}
+
+func (s *CherryPickStep) String() string {
+	if s.Type == NextTurnEventType {
+		return fmt.Sprintf("turn %d: end turn", s.Turn)
+	}
+	state := "off"
+	if s.State {
+		state = "on"
+	}
+	return fmt.Sprintf("turn %d: %s %s", s.Turn, s.Policy, state)
+}
+
+type CherryPickSkip struct {
+	Step CherryPickStep
+	Err error
+}
+
+// CherryPickReport lists which steps were applied to the target branch and
+// which no longer applied.
+type CherryPickReport struct {
+	Target event.ID
+	Applied []CherryPickStep
+	Skipped []CherryPickSkip
+}
+
+func (r *CherryPickReport) String() string {
+	text := fmt.Sprintf("Applied %d steps, skipped %d", len(r.Applied), len(r.Skipped))
+	for _, v := range r.Skipped {
+		text += fmt.Sprintf("\n%s: %s", v.Step.String(), v.Err.Error())
+	}
+	return text
+}
+
+// BranchDecisions reads the policy toggles and turn ends made on the branch
+// from the turn onward, including the ones it inherited from its ancestors.
+func (g *Instance) BranchDecisions(branchID event.ID, fromTurn uint64) ([]CherryPickStep, error) {
+	timeStore := g.GetTimeLineStore()
+	ids, _, err := branchPath(timeStore, branchID)
+	if nil != err {
+		return nil, err
+	}
+	path := []event.Branch{}
+	for _, v := range ids {
+		branch, err := timeStore.GetBranch(v)
+		if nil != err {
+			return nil, &UnknownBranchError{v}
+		}
+		path = append(path, branch)
+	}
+	events := []event.Event{}
+	err = g.EventStore.Restore(^uint64(0), func(e event.Event) error {
+		events = append(events, e)
+		return nil
+	})
+	if nil != err {
+		return nil, err
+	}
+	return decisionSteps(path, events, fromTurn), nil
+}
+
+// inheritedUntil returns for every branch on the path, the branch itself
+// first, the index of the last event the branch sees from it. A branch
+// inherits the events of its parent up to the last event of the parent when
+// it split off, later events of the parent aren't part of its history even
+// when they are at the same turn.
+func inheritedUntil(path []event.Branch, events []event.Event) map[event.ID]int {
+	index := map[event.ID]int{}
+	for k, v := range events {
+		index[v.ID()] = k
+	}
+	until := map[event.ID]int{}
+	limit := len(events)-1
+	for k, v := range path {
+		until[v.BranchID] = limit
+		if k+1 == len(path) {
+			break
+		}
+		last, ok := index[v.PrevBranchLastEvent]
+		if !ok {
+			last = -1
+		}
+		if last < limit {
+			limit = last
+		}
+	}
+	return until
+}
+
+// decisionSteps picks the decisions of the path from the events, path holds
+// the branch first and then its ancestors.
+func decisionSteps(path []event.Branch, events []event.Event, fromTurn uint64) []CherryPickStep {
+	until := inheritedUntil(path, events)
+	inPath := func(branch event.ID, index int) bool {
+		last, ok := until[branch]
+		return ok && index <= last
+	}
+
+	steps := []CherryPickStep{}
+	for k, v := range events {
+		switch e := v.(type) {
+		case *SetPolicyEvent:
+			if !inPath(e.BranchID, k) || e.BranchEventTime < fromTurn {
+				continue
+			}
+			steps = append(steps, CherryPickStep{
+				Turn: e.BranchEventTime,
+				Type: SetPolicyEventType,
+				Policy: e.Policy,
+				State: e.State,
+			})
+		case *NextTurnEvent:
+			if !inPath(e.BranchID, k) || e.BranchEventTime == 0 || e.BranchEventTime-1 < fromTurn {
+				continue
+			}
+			steps = append(steps, CherryPickStep{
+				Turn: e.BranchEventTime-1,
+				Type: NextTurnEventType,
+			})
+		}
+	}
+	return steps
+}
+
+// CherryPick replays the decisions of the source branch from the turn onward
+// onto the current branch, or onto a new branch split off at the current turn.
+// Every step is validated again, steps that no longer apply are skipped and
+// reported.
+func (g *Instance) CherryPick(source event.ID, fromTurn uint64, newBranch bool) (*CherryPickReport, error) {
+	steps, err := g.BranchDecisions(source, fromTurn)
+	if nil != err {
+		return nil, err
+	}
+	if g.GetGameStore().Rewind && !newBranch {
+		return nil, fmt.Errorf("Can't cherry pick onto a rewinded branch, use a new branch")
+	}
+	if newBranch {
+		if _, err := g.BranchOff(); nil != err {
+			return nil, err
+		}
+	}
+
+	report := CherryPickReport{
+		Target: g.GetGameStore().CurrentBranch,
+	}
+	for _, v := range steps {
+		if err := g.cherryPickStep(v); nil != err {
+			report.Skipped = append(report.Skipped, CherryPickSkip{v, err})
+			continue
+		}
+		report.Applied = append(report.Applied, v)
+	}
+	return &report, nil
+}
+
+func (g *Instance) cherryPickStep(step CherryPickStep) error {
+	if step.Type == NextTurnEventType {
+		return g.EndTurn()
+	}
+	// The policy may already be in the wanted state on the target branch.
+	if _, ok := g.GetCurrentBranchStore().ActivePolicies[step.Policy]; ok == step.State {
+		return nil
+	}
+	return g.Execute(g.SetPolicyState(step.Policy, step.State))
+}
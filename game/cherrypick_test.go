@@ -0,0 +1,79 @@
+package game
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/pkartner/event"
+)
+
+func testID(n byte) event.ID {
+	id := event.ID{}
+	id[15] = n
+	return id
+}
+
+func testSetPolicy(id byte, branch event.ID, turn uint64, policy string, state bool) *SetPolicyEvent {
+	e := &SetPolicyEvent{Policy: policy, State: state}
+	e.EventID = testID(id)
+	e.BranchID = branch
+	e.BranchEventTime = turn
+	return e
+}
+
+func testNextTurn(id byte, branch event.ID, turn uint64) *NextTurnEvent {
+	e := &NextTurnEvent{}
+	e.EventID = testID(id)
+	e.BranchID = branch
+	e.BranchEventTime = turn
+	return e
+}
+
+func TestDecisionStepsIgnoresParentAfterFork(t *testing.T) {
+	root := testID(100)
+	child := testID(101)
+	events := []event.Event{
+		testSetPolicy(1, root, 0, "rest", true),
+		testNextTurn(2, root, 1),
+		testSetPolicy(3, root, 1, "collect food", true),
+		// The child splits off here, at turn 1 of the root
+		testSetPolicy(4, root, 1, "build shelter", true),
+		testNextTurn(5, root, 2),
+		testSetPolicy(6, child, 1, "collect resources", true),
+		testNextTurn(7, child, 2),
+	}
+	path := []event.Branch{
+		{BranchID: child, PrevBranch: root, PrevBranchLastEvent: testID(3), CreationTime: 1},
+		{BranchID: root},
+	}
+	tests := []struct {
+		name string
+		path []event.Branch
+		fromTurn uint64
+		want []CherryPickStep
+	}{
+		{"child", path, 0, []CherryPickStep{
+			{Turn: 0, Type: SetPolicyEventType, Policy: "rest", State: true},
+			{Turn: 0, Type: NextTurnEventType},
+			{Turn: 1, Type: SetPolicyEventType, Policy: "collect food", State: true},
+			{Turn: 1, Type: SetPolicyEventType, Policy: "collect resources", State: true},
+			{Turn: 1, Type: NextTurnEventType},
+		}},
+		{"child from the fork turn", path, 1, []CherryPickStep{
+			{Turn: 1, Type: SetPolicyEventType, Policy: "collect food", State: true},
+			{Turn: 1, Type: SetPolicyEventType, Policy: "collect resources", State: true},
+			{Turn: 1, Type: NextTurnEventType},
+		}},
+		{"root", path[1:], 1, []CherryPickStep{
+			{Turn: 1, Type: SetPolicyEventType, Policy: "collect food", State: true},
+			{Turn: 1, Type: SetPolicyEventType, Policy: "build shelter", State: true},
+			{Turn: 1, Type: NextTurnEventType},
+		}},
+	}
+	for _, test := range tests {
+		got := decisionSteps(test.path, events, test.fromTurn)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package game
+
+import (
+	"time"
+
+	"github.com/pkartner/event"
+)
+
+// Clock provides the time new events are stamped with.
+type Clock interface {
+	Now() uint64
+}
+
+type SystemClock struct{}
+
+func (c SystemClock) Now() uint64 {
+	return uint64(time.Now().Unix())
+}
+
+// StepClock starts at Time and advances by Step on every call, for tests
+// and replays that need reproducible event times.
+type StepClock struct {
+	Time uint64
+	Step uint64
+}
+
+func (c *StepClock) Now() uint64 {
+	t := c.Time
+	c.Time += c.Step
+	return t
+}
+
+// IDSource provides the id part of new event ids.
+type IDSource interface {
+	NextIDPart() uint64
+}
+
+// SequenceIDSource hands out increasing id parts following the last event
+// of the dispatcher's store. It remembers what it handed out, so events
+// created before the previous one is dispatched don't share an id.
+type SequenceIDSource struct {
+	Dispatcher *event.TimelineDispatcher
+	last uint64
+}
+
+func (s *SequenceIDSource) NextIDPart() uint64 {
+	part := uint64(0)
+	if nil != s.Dispatcher.Store.LastEvent {
+		part = s.Dispatcher.Store.LastEvent.ID().IDPart()
+	}
+	if part < s.last {
+		part = s.last
+	}
+	s.last = part+1
+	return s.last
+}
+
+// EventStamp returns the id and time for a new event.
+func (g *Instance) EventStamp() (event.ID, uint64) {
+	t := g.Clock.Now()
+	return event.GenerateTimeID(t, g.IDs.NextIDPart()), t
+}
@@ -0,0 +1,46 @@
+package game
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+)
+
+// playLoggedTestGame plays the test game in a new save and returns its
+// exported event log.
+func playLoggedTestGame(t *testing.T, gameData *Data) []byte {
+	t.Helper()
+	g := newTestGame(t, filepath.Join(t.TempDir(), "save"), gameData)
+	defer g.Close()
+	playTestGame(t, g)
+	log := bytes.Buffer{}
+	must(t, g.ExportEventLog(&log))
+	return log.Bytes()
+}
+
+func TestStepClockRunsAreIdentical(t *testing.T) {
+	gameData := testData(t)
+	first := playLoggedTestGame(t, gameData)
+	second := playLoggedTestGame(t, gameData)
+	if !bytes.Equal(first, second) {
+		t.Fatalf("the event logs differ:\n%s\n%s", first, second)
+	}
+
+	ids := map[string]struct{}{}
+	scanner := bufio.NewScanner(bytes.NewReader(first))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := EventLogLine{}
+		must(t, json.Unmarshal(scanner.Bytes(), &line))
+		if _, ok := ids[line.ID]; ok {
+			t.Errorf("event id %s is used twice", line.ID)
+		}
+		ids[line.ID] = struct{}{}
+	}
+	must(t, scanner.Err())
+	if len(ids) < 20 {
+		t.Errorf("got %d events, the test game should have more", len(ids))
+	}
+}
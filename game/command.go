@@ -0,0 +1,284 @@
+package game
+
+import (
+	"fmt"
+
+	"github.com/pkartner/event"
+)
+
+type UnknownPolicyError struct {
+	Policy string
+}
+
+func (e *UnknownPolicyError) Error() string {
+	return fmt.Sprintf("Unknown policy %s", e.Policy)
+}
+
+type PolicyUnavailableError struct {
+	Policy string
+}
+
+func (e *PolicyUnavailableError) Error() string {
+	return fmt.Sprintf("Policy %s is not available in this scenario", e.Policy)
+}
+
+type PolicyLockedError struct {
+	Lock *PolicyLock
+}
+
+func (e *PolicyLockedError) Error() string {
+	state := "off"
+	if e.Lock.State {
+		state = "on"
+	}
+	until := "for the rest of the game"
+	if e.Lock.UntilTurn != 0 {
+		until = fmt.Sprintf("until turn %d", e.Lock.UntilTurn)
+	}
+	if e.Lock.Reason == "" {
+		return fmt.Sprintf("Policy %s is locked %s %s", e.Lock.Policy, state, until)
+	}
+	return fmt.Sprintf("Policy %s is locked %s %s: %s", e.Lock.Policy, state, until, e.Lock.Reason)
+}
+
+type PolicyRestrictedError struct {
+	Policy string
+	ValueName string
+	Required float64
+	Actual float64
+}
+
+func (e *PolicyRestrictedError) Error() string {
+	return fmt.Sprintf("Policy %s needs at least %g %s, there is %.2f", e.Policy, e.Required, e.ValueName, e.Actual)
+}
+
+type GameOverError struct {
+	Branch event.ID
+	GameOver uint8
+}
+
+func (e *GameOverError) Error() string {
+	if e.GameOver == GameWon {
+		return fmt.Sprintf("Branch %s is already won", e.Branch.ToString())
+	}
+	return fmt.Sprintf("Branch %s is already lost", e.Branch.ToString())
+}
+
+type UnknownBranchError struct {
+	Branch event.ID
+}
+
+func (e *UnknownBranchError) Error() string {
+	return fmt.Sprintf("Unknown branch %s", e.Branch.ToString())
+}
+
+type UnknownTurnError struct {
+	Node Node
+}
+
+func (e *UnknownTurnError) Error() string {
+	return fmt.Sprintf("Branch %s has no turn %d", e.Node.Branch.ToString(), e.Node.Turn)
+}
+
+type BranchInUseError struct {
+	Branch event.ID
+}
+
+func (e *BranchInUseError) Error() string {
+	return fmt.Sprintf("Branch %s leads to the current branch", e.Branch.ToString())
+}
+
+type RewoundError struct {
+	Node Node
+}
+
+func (e *RewoundError) Error() string {
+	return fmt.Sprintf("Branch %s is rewound to turn %d, start a new branch to change it", e.Node.Branch.ToString(), e.Node.Turn)
+}
+
+type UnknownValueError struct {
+	Value string
+}
+
+func (e *UnknownValueError) Error() string {
+	return fmt.Sprintf("Unknown value %s", e.Value)
+}
+
+// Execute validates the event against the current state and dispatches it
+// when it is valid. Player actions go through here instead of being
+// dispatched directly.
+func (g *Instance) Execute(e event.Event) error {
+	if err := g.Validate(e); nil != err {
+		return err
+	}
+	g.Dispatch(e)
+	return nil
+}
+
+// Dispatch hands the event to the dispatcher without validation, for events
+// the game creates itself.
+func (g *Instance) Dispatch(e event.Event) {
+	g.Dispatcher.Dispatch(e)
+	g.applyProjections(e)
+	g.eventCount++
+	g.snapshotIfDue()
+}
+
+// Validate returns why the event can't be applied, nil when it can.
+func (g *Instance) Validate(e event.Event) error {
+	switch e := e.(type) {
+	case *SetPolicyEvent:
+		return g.validateSetPolicy(e)
+	case *NextTurnEvent:
+		store, err := g.branchStore(e.BranchID)
+		if nil != err {
+			return err
+		}
+		return g.validateNextTurn(e.BranchID, store)
+	case *SetBranchEvent:
+		if _, err := g.branchStore(e.BranchID); nil != err {
+			return err
+		}
+	case *SetCompareBranchEvent:
+		if _, err := g.branchStore(e.BranchID); nil != err {
+			return err
+		}
+	case *SetBranchNameEvent:
+		if _, err := g.branchStore(e.BranchID); nil != err {
+			return err
+		}
+	case *SetBranchNoteEvent:
+		if _, err := g.branchStore(e.BranchID); nil != err {
+			return err
+		}
+	case *ArchiveBranchEvent:
+		return g.validateRemoveBranch(e.BranchID, e.State)
+	case *DeleteBranchEvent:
+		return g.validateRemoveBranch(e.BranchID, true)
+	case *TemporalEnergyEvent:
+		if e.Amount < 0 {
+			return g.checkEnergy(-e.Amount)
+		}
+	case *DiscoveryEvent:
+		if nil == g.GameData.Scenario.Discovery(e.Discovery) {
+			return &UnknownDiscoveryError{e.Discovery}
+		}
+		return g.validateNode(e.Node)
+	case *SetTurnNoteEvent:
+		return g.validateNode(e.Node)
+	case *SetBookmarkEvent:
+		return g.validateNode(e.Node)
+	case *SetSelectedValueEvent:
+		if _, ok := g.GameData.Values.Values[e.Value]; !ok {
+			return &UnknownValueError{e.Value}
+		}
+	}
+	return nil
+}
+
+// validateNextTurn checks ending the turn on the branch in the state of the
+// store.
+func (g *Instance) validateNextTurn(branchID event.ID, store *BranchStore) error {
+	if timeline := g.GetGameStore().Timeline; timeline != 0 {
+		return &TimelineOverError{timeline}
+	}
+	if store.GameOver != 0 {
+		return &GameOverError{branchID, store.GameOver}
+	}
+	return nil
+}
+
+func (g *Instance) validateSetPolicy(e *SetPolicyEvent) error {
+	if _, ok := g.GameData.Policies.Policies[e.Policy]; !ok {
+		return &UnknownPolicyError{e.Policy}
+	}
+	store, err := g.branchStore(e.BranchID)
+	if nil != err {
+		return err
+	}
+	return g.validatePolicyState(e.Policy, e.State, e.BranchID, store)
+}
+
+// validatePolicyState checks setting the policy on the branch in the state
+// of the store.
+func (g *Instance) validatePolicyState(name string, state bool, branchID event.ID, store *BranchStore) error {
+	policy, ok := g.GameData.Policies.Policies[name]
+	if !ok {
+		return &UnknownPolicyError{name}
+	}
+	scenario := &g.GameData.Scenario
+	if !scenario.PolicyAvailable(name) {
+		return &PolicyUnavailableError{name}
+	}
+	if timeline := g.GetGameStore().Timeline; timeline != 0 {
+		return &TimelineOverError{timeline}
+	}
+	if store.GameOver != 0 {
+		return &GameOverError{branchID, store.GameOver}
+	}
+	if lock := scenario.PolicyLock(name, store.Turn); nil != lock {
+		return &PolicyLockedError{lock}
+	}
+	if !state {
+		return nil
+	}
+	if discovery := scenario.PolicyDiscovery(name); discovery != "" && !g.GetGameStore().Discovered(discovery) {
+		return &PolicyUndiscoveredError{name, discovery}
+	}
+	for _, v := range MutualExclusivePolicies(g.GameData.Policies, name) {
+		if lock := scenario.PolicyLock(v, store.Turn); nil != lock && lock.State {
+			return &PolicyLockedError{lock}
+		}
+	}
+	for _, v := range policy.Restrictions {
+		if store.Values[v.ValueName] < v.Amount {
+			return &PolicyRestrictedError{name, v.ValueName, v.Amount, store.Values[v.ValueName]}
+		}
+	}
+	return nil
+}
+
+func (g *Instance) validateNode(node Node) error {
+	store, err := g.branchStore(node.Branch)
+	if nil != err {
+		return err
+	}
+	if node.Turn > store.Turn {
+		return &UnknownTurnError{node}
+	}
+	return nil
+}
+
+// validateRemoveBranch makes sure the branch exists and, when it is about to
+// be hidden, that the current branch doesn't split off from it.
+func (g *Instance) validateRemoveBranch(branchID event.ID, remove bool) error {
+	if _, err := g.branchStore(branchID); nil != err {
+		return err
+	}
+	if !remove {
+		return nil
+	}
+	path, _, err := branchPath(g.GetTimeLineStore(), g.GetGameStore().CurrentBranch)
+	if nil != err {
+		return err
+	}
+	for _, v := range path {
+		if v == branchID {
+			return &BranchInUseError{branchID}
+		}
+	}
+	return nil
+}
+
+func (g *Instance) branchStore(branchID event.ID) (*BranchStore, error) {
+	timeStore := g.GetTimeLineStore()
+	index, ok := timeStore.BranchDictionary[branchID]
+	if !ok {
+		return nil, &UnknownBranchError{branchID}
+	}
+	if _, ok := g.GetGameStore().DeletedBranches[branchID]; ok {
+		return nil, &UnknownBranchError{branchID}
+	}
+	branch := timeStore.Branches[index]
+	return GetBranchStore(&timeStore.Stores[branch.StoreID]), nil
+}
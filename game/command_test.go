@@ -0,0 +1,71 @@
+package game
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/pkartner/event"
+)
+
+func TestExecuteRejectsInvalidEvents(t *testing.T) {
+	g := newTestGame(t, filepath.Join(t.TempDir(), "save"), testData(t))
+	defer g.Close()
+	// Rest is locked on until turn 3
+	for i := 0; i < 3; i++ {
+		must(t, g.EndTurn())
+	}
+	root := g.GetGameStore().CurrentBranch
+	gameOver := func() func() {
+		g.GetCurrentBranchStore().GameOver = GameLost
+		return func() { g.GetCurrentBranchStore().GameOver = 0 }
+	}
+	timelineOver := func() func() {
+		g.GetGameStore().Timeline = GameWon
+		return func() { g.GetGameStore().Timeline = 0 }
+	}
+	tests := []struct {
+		name string
+		setup func() func()
+		event func() event.Event
+		err error
+	}{
+		{"policy", nil, func() event.Event { return g.SetPolicy("collect food") }, nil},
+		{"unknown policy", nil, func() event.Event { return g.SetPolicy("gold") }, &UnknownPolicyError{}},
+		{"restricted policy", nil, func() event.Event { return g.SetPolicy("build shelter") }, &PolicyRestrictedError{}},
+		{"policy after game over", gameOver, func() event.Event { return g.SetPolicy("collect food") }, &GameOverError{}},
+		{"policy after timeline", timelineOver, func() event.Event { return g.SetPolicy("collect food") }, &TimelineOverError{}},
+		{"next turn", nil, func() event.Event { return g.NextTurn() }, nil},
+		{"next turn after game over", gameOver, func() event.Event { return g.NextTurn() }, &GameOverError{}},
+		{"next turn after timeline", timelineOver, func() event.Event { return g.NextTurn() }, &TimelineOverError{}},
+		{"unknown branch", nil, func() event.Event { return g.SetBranch(testID(200)) }, &UnknownBranchError{}},
+		{"unknown turn", nil, func() event.Event { return g.SetTurnNote(Node{root, 10}, "note") }, &UnknownTurnError{}},
+		{"delete current branch", nil, func() event.Event { return g.DeleteBranch(root) }, &BranchInUseError{}},
+		{"too much energy", nil, func() event.Event { return g.ChangeTemporalEnergy(-100, "test") }, &TemporalEnergyError{}},
+		{"unknown discovery", nil, func() event.Event { return g.Discover("gold") }, &UnknownDiscoveryError{}},
+		{"unknown value", nil, func() event.Event { return g.SetSelectedValue("gold") }, &UnknownValueError{}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if nil != test.setup {
+				defer test.setup()()
+			}
+			e := test.event()
+			if nil == test.err {
+				must(t, g.Validate(e))
+				return
+			}
+			eventCount := g.eventCount
+			err := g.Execute(e)
+			if nil == err || reflect.TypeOf(err) != reflect.TypeOf(test.err) {
+				t.Fatalf("got error %v, want %T", err, test.err)
+			}
+			if err.Error() == "" {
+				t.Error("the error has no message")
+			}
+			if g.eventCount != eventCount {
+				t.Error("the invalid event was dispatched")
+			}
+		})
+	}
+}
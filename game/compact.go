@@ -0,0 +1,120 @@
+package game
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pkartner/event"
+)
+
+// eventBranches returns the branches the event refers to.
+func eventBranches(e event.Event) []event.ID {
+	switch e := e.(type) {
+	case *event.NewBranchEvent:
+		return []event.ID{e.NewBranchID}
+	case *NextTurnEvent:
+		return []event.ID{e.BranchID}
+	case *SetPolicyEvent:
+		return []event.ID{e.BranchID}
+	case *StoryBeatEvent:
+		return []event.ID{e.BranchID}
+	case *SetBranchEvent:
+		return []event.ID{e.BranchID}
+	case *SetCompareBranchEvent:
+		return []event.ID{e.BranchID}
+	case *SetBranchNameEvent:
+		return []event.ID{e.BranchID}
+	case *SetBranchNoteEvent:
+		return []event.ID{e.BranchID}
+	case *SetTurnNoteEvent:
+		return []event.ID{e.Node.Branch}
+	case *SetBookmarkEvent:
+		return []event.ID{e.Node.Branch}
+	case *DiscoveryEvent:
+		return []event.ID{e.Node.Branch}
+	case *ArchiveBranchEvent:
+		return []event.ID{e.BranchID}
+	}
+	return []event.ID{}
+}
+
+// CompactSave rewrites the save without the events of deleted branches, the
+// branches are gone for good afterwards. It returns the number of events
+// that were dropped.
+func CompactSave(fileName string, gameData *Data) (int, error) {
+	g, err := NewGame(fileName, gameData)
+	if nil != err {
+		return 0, err
+	}
+	modified := g.Chain.Modified
+	deleted := g.GetGameStore().DeletedBranches
+	if len(deleted) == 0 {
+		return 0, g.Close()
+	}
+	events := []event.Event{}
+	err = g.EventStore.Restore(^uint64(0), func(e event.Event) error {
+		events = append(events, e)
+		return nil
+	})
+	if nil != err {
+		g.Close()
+		return 0, err
+	}
+	if err := g.Close(); nil != err {
+		return 0, err
+	}
+
+	kept := []event.Event{}
+	currentBranch := event.ZeroID()
+	for _, v := range events {
+		if e, ok := v.(*SetBranchEvent); ok {
+			currentBranch = e.BranchID
+		}
+		switch v.(type) {
+		case *DeleteBranchEvent:
+			continue
+		case *event.WindbackEvent:
+			// Windbacks apply to the branch the player was on
+			if _, ok := deleted[currentBranch]; ok {
+				continue
+			}
+		}
+		drop := false
+		for _, v2 := range eventBranches(v) {
+			if _, ok := deleted[v2]; ok {
+				drop = true
+			}
+		}
+		if drop {
+			continue
+		}
+		kept = append(kept, v)
+	}
+
+	compactFileName := fileName+".compact"
+	if err := os.Remove(compactFileName+".db"); nil != err && !os.IsNotExist(err) {
+		return 0, err
+	}
+	compacted, err := NewGame(compactFileName, gameData)
+	if nil != err {
+		return 0, err
+	}
+	for _, v := range kept {
+		compacted.Dispatch(v)
+	}
+	// Rewriting the events gives a valid chain, a modified save has to stay
+	// marked as modified.
+	if modified {
+		if err := compacted.Chain.MarkModified(); nil != err {
+			compacted.Close()
+			return 0, err
+		}
+	}
+	if err := compacted.Close(); nil != err {
+		return 0, err
+	}
+	if err := os.Rename(compactFileName+".db", fileName+".db"); nil != err {
+		return 0, fmt.Errorf("Could not replace %s with the compacted save: %s", fileName, err)
+	}
+	return len(events)-len(kept), nil
+}
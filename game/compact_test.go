@@ -0,0 +1,46 @@
+package game
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/pkartner/event"
+)
+
+func TestCompactSaveDropsDeletedBranches(t *testing.T) {
+	gameData := testData(t)
+	gameData.Scenario.Discoveries = []Discovery{{ID: "cave", Text: "A cave"}}
+	fileName := filepath.Join(t.TempDir(), "save")
+	g := newTestGame(t, fileName, gameData)
+	playTestGame(t, g)
+	root := g.GetTimeLineStore().Branches[0].BranchID
+	child := g.GetGameStore().CurrentBranch
+	g.Dispatch(g.Discover("cave"))
+	must(t, g.Execute(g.SetBranch(root)))
+	must(t, g.Execute(g.DeleteBranch(child)))
+	must(t, g.Close())
+
+	dropped, err := CompactSave(fileName, gameData)
+	must(t, err)
+	if dropped == 0 {
+		t.Fatal("no events were dropped")
+	}
+	g, err = NewGame(fileName, gameData)
+	must(t, err)
+	defer g.Close()
+	err = g.EventStore.Restore(^uint64(0), func(e event.Event) error {
+		for _, v := range eventBranches(e) {
+			if v == child {
+				t.Errorf("%s event of the deleted branch was kept", e.Type())
+			}
+		}
+		return nil
+	})
+	must(t, err)
+	if g.GetGameStore().Discovered("cave") {
+		t.Error("the discovery on the deleted branch was kept")
+	}
+	if len(g.GetTimeLineStore().Branches) != 1 {
+		t.Errorf("got %d branches, want 1", len(g.GetTimeLineStore().Branches))
+	}
+}
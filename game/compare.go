@@ -0,0 +1,308 @@
+package game
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/pkartner/event"
+)
+
+// BranchDiffRow holds the state of both branches at a turn, A or B is nil
+// when that branch didn't reach the turn.
+type BranchDiffRow struct {
+	Turn uint64
+	A *TurnState
+	B *TurnState
+	NoteA string
+	NoteB string
+}
+
+// ValueDiff returns B minus A for the value, false when either branch
+// didn't reach the turn.
+func (r *BranchDiffRow) ValueDiff(value string) (float64, bool) {
+	if nil == r.A || nil == r.B {
+		return 0, false
+	}
+	return r.B.Values[value]-r.A.Values[value], true
+}
+
+// PolicyDiff returns the policies only active on A and only active on B.
+func (r *BranchDiffRow) PolicyDiff() ([]string, []string) {
+	onlyA := []string{}
+	onlyB := []string{}
+	if nil == r.A || nil == r.B {
+		return onlyA, onlyB
+	}
+	inB := map[string]struct{}{}
+	for _, v := range r.B.ActivePolicies {
+		inB[v] = struct{}{}
+	}
+	inA := map[string]struct{}{}
+	for _, v := range r.A.ActivePolicies {
+		inA[v] = struct{}{}
+		if _, ok := inB[v]; !ok {
+			onlyA = append(onlyA, v)
+		}
+	}
+	for _, v := range r.B.ActivePolicies {
+		if _, ok := inA[v]; !ok {
+			onlyB = append(onlyB, v)
+		}
+	}
+	return onlyA, onlyB
+}
+
+// BranchDiff aligns two branches by turn starting at the turn they split
+// off from their common ancestor.
+type BranchDiff struct {
+	A event.ID
+	B event.ID
+	CommonAncestor event.ID
+	ForkTurn uint64
+	Rows []BranchDiffRow
+	OutcomeA uint8
+	OutcomeB uint8
+	BranchNoteA string
+	BranchNoteB string
+}
+
+// branchPath returns the branch followed by its ancestors, together with
+// the turn each of them was split off from its parent.
+func branchPath(timeStore *event.TimelineStore, branchID event.ID) ([]event.ID, []uint64, error) {
+	path := []event.ID{}
+	forks := []uint64{}
+	for branchID != event.ZeroID() {
+		branch, err := timeStore.GetBranch(branchID)
+		if nil != err {
+			return nil, nil, &UnknownBranchError{branchID}
+		}
+		path = append(path, branch.BranchID)
+		forks = append(forks, branch.CreationTime)
+		branchID = branch.PrevBranch
+	}
+	return path, forks, nil
+}
+
+func (g *Instance) CompareBranches(a event.ID, b event.ID) (*BranchDiff, error) {
+	timeStore := g.GetTimeLineStore()
+	pathA, forksA, err := branchPath(timeStore, a)
+	if nil != err {
+		return nil, err
+	}
+	pathB, forksB, err := branchPath(timeStore, b)
+	if nil != err {
+		return nil, err
+	}
+	indexA := map[event.ID]int{}
+	for k, v := range pathA {
+		indexA[v] = k
+	}
+	diff := BranchDiff{
+		A: a,
+		B: b,
+	}
+	found := false
+	forkTurn := ^uint64(0)
+	for k, v := range pathB {
+		k2, ok := indexA[v]
+		if !ok {
+			continue
+		}
+		diff.CommonAncestor = v
+		if k > 0 && forksB[k-1] < forkTurn {
+			forkTurn = forksB[k-1]
+		}
+		if k2 > 0 && forksA[k2-1] < forkTurn {
+			forkTurn = forksA[k2-1]
+		}
+		found = true
+		break
+	}
+	if !found {
+		return nil, fmt.Errorf("Branches %s and %s have no common ancestor", a.ToString(), b.ToString())
+	}
+
+	storeA, err := g.branchStore(a)
+	if nil != err {
+		return nil, err
+	}
+	storeB, err := g.branchStore(b)
+	if nil != err {
+		return nil, err
+	}
+	if forkTurn == ^uint64(0) {
+		forkTurn = storeA.Turn
+	}
+	diff.ForkTurn = forkTurn
+	diff.OutcomeA = storeA.GameOver
+	diff.OutcomeB = storeB.GameOver
+	gameStore := g.GetGameStore()
+	diff.BranchNoteA = gameStore.BranchNotes[a]
+	diff.BranchNoteB = gameStore.BranchNotes[b]
+
+	statesA := storeA.States()
+	statesB := storeB.States()
+	lastTurn := storeA.Turn
+	if storeB.Turn > lastTurn {
+		lastTurn = storeB.Turn
+	}
+	for turn := forkTurn; turn <= lastTurn; turn++ {
+		diff.Rows = append(diff.Rows, BranchDiffRow{
+			Turn: turn,
+			A: stateAt(statesA, turn),
+			B: stateAt(statesB, turn),
+			NoteA: g.TurnNote(a, turn),
+			NoteB: g.TurnNote(b, turn),
+		})
+	}
+
+	return &diff, nil
+}
+
+func stateAt(states []TurnState, turn uint64) *TurnState {
+	for k := range states {
+		if states[k].Turn == turn {
+			return &states[k]
+		}
+	}
+	return nil
+}
+
+func OutcomeString(gameOver uint8) string {
+	switch gameOver {
+	case GameWon:
+		return "won"
+	case GameLost:
+		return "lost"
+	}
+	return "playing"
+}
+
+// WriteCSV writes a row per turn with the values and policies of both
+// branches and the difference between them.
+func (d *BranchDiff) WriteCSV(w io.Writer) error {
+	valueSet := map[string]struct{}{}
+	for _, v := range d.Rows {
+		for _, state := range []*TurnState{v.A, v.B} {
+			if nil == state {
+				continue
+			}
+			for k := range state.Values {
+				valueSet[k] = struct{}{}
+			}
+		}
+	}
+	valueNames := []string{}
+	for k := range valueSet {
+		valueNames = append(valueNames, k)
+	}
+	sort.Strings(valueNames)
+
+	writer := csv.NewWriter(w)
+	header := []string{"turn"}
+	for _, v := range valueNames {
+		header = append(header, v+"_a", v+"_b", v+"_diff")
+	}
+	header = append(header, "policies_a", "policies_b", "outcome_a", "outcome_b", "note_a", "note_b")
+	if err := writer.Write(header); nil != err {
+		return err
+	}
+	for _, v := range d.Rows {
+		record := []string{fmt.Sprintf("%d", v.Turn)}
+		for _, v2 := range valueNames {
+			record = append(record, stateValue(v.A, v2), stateValue(v.B, v2))
+			diff, ok := v.ValueDiff(v2)
+			if ok {
+				record = append(record, fmt.Sprintf("%g", diff))
+			} else {
+				record = append(record, "")
+			}
+		}
+		record = append(record, statePolicies(v.A), statePolicies(v.B), stateOutcome(v.A), stateOutcome(v.B), v.NoteA, v.NoteB)
+		if err := writer.Write(record); nil != err {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func stateValue(state *TurnState, value string) string {
+	if nil == state {
+		return ""
+	}
+	return fmt.Sprintf("%g", state.Values[value])
+}
+
+func statePolicies(state *TurnState) string {
+	if nil == state {
+		return ""
+	}
+	return strings.Join(state.ActivePolicies, ";")
+}
+
+func stateOutcome(state *TurnState) string {
+	if nil == state {
+		return ""
+	}
+	return OutcomeString(state.GameOver)
+}
+
+// Lines describes the diff as text, a line per turn with the values that
+// differ, the policies only active on one of the branches and the notes.
+func (d *BranchDiff) Lines(gameData *Data) []string {
+	lines := []string{}
+	if d.BranchNoteA != "" {
+		lines = append(lines, "A: "+d.BranchNoteA)
+	}
+	if d.BranchNoteB != "" {
+		lines = append(lines, "B: "+d.BranchNoteB)
+	}
+	for _, v := range d.Rows {
+		if v.NoteA != "" {
+			lines = append(lines, fmt.Sprintf("%03d note A: %s", v.Turn, v.NoteA))
+		}
+		if v.NoteB != "" && v.NoteB != v.NoteA {
+			lines = append(lines, fmt.Sprintf("%03d note B: %s", v.Turn, v.NoteB))
+		}
+		if nil == v.A || nil == v.B {
+			state := v.A
+			label := "A"
+			if nil == state {
+				state = v.B
+				label = "B"
+			}
+			lines = append(lines, fmt.Sprintf("%03d only on %s", v.Turn, label))
+			continue
+		}
+		parts := []string{}
+		valueNames := []string{}
+		for k := range v.A.Values {
+			valueNames = append(valueNames, k)
+		}
+		sort.Strings(valueNames)
+		for _, v2 := range valueNames {
+			diff, _ := v.ValueDiff(v2)
+			if diff == 0 {
+				continue
+			}
+			parts = append(parts, fmt.Sprintf("%s %.2f/%.2f", gameData.ValueName(v2), v.A.Values[v2], v.B.Values[v2]))
+		}
+		onlyA, onlyB := v.PolicyDiff()
+		for _, v2 := range onlyA {
+			parts = append(parts, "A: "+gameData.PolicyName(v2))
+		}
+		for _, v2 := range onlyB {
+			parts = append(parts, "B: "+gameData.PolicyName(v2))
+		}
+		if len(parts) == 0 {
+			parts = append(parts, "same")
+		}
+		lines = append(lines, fmt.Sprintf("%03d %s", v.Turn, strings.Join(parts, ", ")))
+	}
+	lines = append(lines, fmt.Sprintf("outcome A: %s, B: %s", OutcomeString(d.OutcomeA), OutcomeString(d.OutcomeB)))
+	return lines
+}
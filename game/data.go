@@ -0,0 +1,197 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+)
+
+const (
+	PoliciesFile = "policies.json"
+	ValuesFile = "values.json"
+	ScenarioFile = "scenario.json"
+	AchievementsFile = "achievements.json"
+)
+
+// DataRoot is a directory holding data files, the name is recorded in saves
+// when the root is used as a mod.
+type DataRoot struct {
+	Name string
+	FS fs.FS
+}
+
+// LoadData reads the data files from every root in order. The first root is
+// the base data, every following root is a mod that adds or overrides
+// entries of the roots before it. The base data needs the policies, values
+// and scenario files, other files missing from a root are skipped. The
+// locale selects which locale file is read from each root, at least one root
+// has to have it.
+func LoadData(roots []DataRoot, locale string) *Data {
+	gameData := &Data{}
+	localeFound := false
+	for k, root := range roots {
+		overlay := &Data{}
+		base := k == 0
+		if _, err := readDataFile(root, PoliciesFile, &overlay.Policies, base); nil != err {
+			panic(err)
+		}
+		if _, err := readDataFile(root, ValuesFile, &overlay.Values, base); nil != err {
+			panic(err)
+		}
+		if _, err := readDataFile(root, ScenarioFile, &overlay.Scenario, base); nil != err {
+			panic(err)
+		}
+		if _, err := readDataFile(root, AchievementsFile, &overlay.Achievements, false); nil != err {
+			panic(err)
+		}
+		if locale != "" {
+			found, err := readDataFile(root, LocaleFile(locale), &overlay.Locale, false)
+			if nil != err {
+				panic(err)
+			}
+			localeFound = localeFound || found
+		}
+		gameData.Merge(overlay)
+		if k > 0 {
+			gameData.Mods = append(gameData.Mods, root.Name)
+		}
+	}
+	if locale != "" && !localeFound {
+		panic(fmt.Errorf("No data root has the locale %s", locale))
+	}
+
+	return gameData
+}
+
+// readDataFile reads the file into v and returns if it was there, a missing
+// file is an error when it is required.
+func readDataFile(root DataRoot, fileName string, v interface{}, required bool) (bool, error) {
+	data, err := fs.ReadFile(root.FS, fileName)
+	if errors.Is(err, fs.ErrNotExist) {
+		if required {
+			return false, fmt.Errorf("Data file %s/%s is missing", root.Name, fileName)
+		}
+		return false, nil
+	}
+	if nil != err {
+		return false, err
+	}
+	if err := json.Unmarshal(data, v); nil != err {
+		return false, fmt.Errorf("Could not parse %s/%s: %s", root.Name, fileName, err)
+	}
+	return true, nil
+}
+
+// Merge applies the overlay on top of d. Values, policies, achievements, start
+// values and locale strings are overridden by key, story beats and discoveries by id and other scenario
+// fields are replaced when the overlay sets them. A mutual exclusive group replaces every group
+// of d it shares a policy with and is added otherwise.
+func (d *Data) Merge(overlay *Data) {
+	if nil == d.Values.Values {
+		d.Values.Values = overlay.Values.Values
+	} else {
+		for k, v := range overlay.Values.Values {
+			d.Values.Values[k] = v
+		}
+	}
+	if nil == d.Policies.Policies {
+		d.Policies.Policies = overlay.Policies.Policies
+	} else {
+		for k, v := range overlay.Policies.Policies {
+			d.Policies.Policies[k] = v
+		}
+	}
+	if nil == d.Achievements.Achievements {
+		d.Achievements.Achievements = overlay.Achievements.Achievements
+	} else {
+		for k, v := range overlay.Achievements.Achievements {
+			d.Achievements.Achievements[k] = v
+		}
+	}
+	for _, v := range overlay.Policies.MutualExclusive {
+		d.Policies.MutualExclusive = mergeMutualExclusive(d.Policies.MutualExclusive, v)
+	}
+
+	if nil == d.Scenario.StartValues {
+		d.Scenario.StartValues = overlay.Scenario.StartValues
+	} else {
+		for k, v := range overlay.Scenario.StartValues {
+			d.Scenario.StartValues[k] = v
+		}
+	}
+	if overlay.Scenario.Name != "" {
+		d.Scenario.Name = overlay.Scenario.Name
+	}
+	if nil != overlay.Scenario.WinCondition {
+		d.Scenario.WinCondition = overlay.Scenario.WinCondition
+	}
+	if nil != overlay.Scenario.LoseCondition {
+		d.Scenario.LoseCondition = overlay.Scenario.LoseCondition
+	}
+	if nil != overlay.Scenario.AvailablePolicies {
+		d.Scenario.AvailablePolicies = overlay.Scenario.AvailablePolicies
+	}
+	if nil != overlay.Scenario.StartPolicies {
+		d.Scenario.StartPolicies = overlay.Scenario.StartPolicies
+	}
+	if nil != overlay.Scenario.LockedPolicies {
+		d.Scenario.LockedPolicies = overlay.Scenario.LockedPolicies
+	}
+	if nil != overlay.Scenario.TemporalEnergy {
+		d.Scenario.TemporalEnergy = overlay.Scenario.TemporalEnergy
+	}
+	if nil != overlay.Scenario.TimelineWin {
+		d.Scenario.TimelineWin = overlay.Scenario.TimelineWin
+	}
+	if nil != overlay.Scenario.TimelineLose {
+		d.Scenario.TimelineLose = overlay.Scenario.TimelineLose
+	}
+	if nil != overlay.Scenario.Intro {
+		d.Scenario.Intro = overlay.Scenario.Intro
+	}
+	d.Scenario.Story = mergeStory(d.Scenario.Story, overlay.Scenario.Story)
+	d.Scenario.Discoveries = mergeDiscoveries(d.Scenario.Discoveries, overlay.Scenario.Discoveries)
+
+	if nil == d.Locale {
+		d.Locale = overlay.Locale
+	} else {
+		for k, v := range overlay.Locale {
+			d.Locale[k] = v
+		}
+	}
+}
+
+func mergeMutualExclusive(groups [][]string, group []string) [][]string {
+	members := map[string]struct{}{}
+	for _, v := range group {
+		members[v] = struct{}{}
+	}
+	newGroups := [][]string{}
+	for _, v := range groups {
+		shared := false
+		for _, v2 := range v {
+			if _, ok := members[v2]; ok {
+				shared = true
+				break
+			}
+		}
+		if shared {
+			continue
+		}
+		newGroups = append(newGroups, v)
+	}
+
+	return append(newGroups, group)
+}
+
+// Dump writes the effective data as indented json.
+func (d *Data) Dump(w io.Writer) error {
+	data, err := json.MarshalIndent(d, "", "    ")
+	if nil != err {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
@@ -0,0 +1,87 @@
+package game
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadDataOverlays(t *testing.T) {
+	base := DataRoot{Name: "data", FS: os.DirFS(filepath.Join("..", "data"))}
+	harsh := DataRoot{Name: "harsh", FS: fstest.MapFS{
+		ValuesFile: {Data: []byte(`{"values": {
+			"food": {"name": "food", "natural_change": -2},
+			"morale": {"name": "morale"}
+		}}`)},
+		PoliciesFile: {Data: []byte(`{
+			"policies": {"pray": {"name": "pray"}},
+			"mutual_exclusive": [["rest", "pray"]]
+		}`)},
+		ScenarioFile: {Data: []byte(`{"name": "harsh", "start_values": {"food": 2}}`)},
+		LocaleFile("nl"): {Data: []byte(`{"ui.end_turn": "Volgende"}`)},
+	}}
+	harsher := DataRoot{Name: "harsher", FS: fstest.MapFS{
+		ValuesFile: {Data: []byte(`{"values": {"food": {"name": "food", "natural_change": -3}}}`)},
+		ScenarioFile: {Data: []byte(`{"start_values": {"food": 1}}`)},
+	}}
+	gameData := LoadData([]DataRoot{base, harsh, harsher}, "nl")
+	baseData := LoadData([]DataRoot{base}, "nl")
+
+	if !reflect.DeepEqual(gameData.Mods, []string{"harsh", "harsher"}) {
+		t.Errorf("got mods %v, want harsh and harsher", gameData.Mods)
+	}
+	if got := gameData.Values.Values["food"].NaturalChange; got != -3 {
+		t.Errorf("food changes by %v, want the last mod's -3", got)
+	}
+	if _, ok := gameData.Values.Values["morale"]; !ok {
+		t.Error("the value added by a mod is missing")
+	}
+	if !reflect.DeepEqual(gameData.Values.Values["health"], baseData.Values.Values["health"]) {
+		t.Error("a value no mod touches changed")
+	}
+	if _, ok := gameData.Policies.Policies["collect food"]; !ok {
+		t.Error("a base policy is missing")
+	}
+	if !reflect.DeepEqual(gameData.Policies.MutualExclusive, [][]string{{"rest", "pray"}}) {
+		t.Errorf("got mutual exclusive groups %v, want the group of the mod only", gameData.Policies.MutualExclusive)
+	}
+	if gameData.Scenario.StartValues["food"] != 1 || gameData.Scenario.StartValues["health"] != baseData.Scenario.StartValues["health"] {
+		t.Errorf("got start values %v", gameData.Scenario.StartValues)
+	}
+	if gameData.Scenario.Name != "harsh" {
+		t.Errorf("got scenario %s, want harsh", gameData.Scenario.Name)
+	}
+	if nil == gameData.Scenario.WinCondition {
+		t.Error("the win condition of the base scenario was dropped")
+	}
+	if gameData.Text("ui.end_turn", "") != "Volgende" || gameData.Text("ui.new_branch", "") != baseData.Text("ui.new_branch", "") {
+		t.Error("the locale strings weren't merged by key")
+	}
+}
+
+func TestLoadDataFails(t *testing.T) {
+	base := DataRoot{Name: "data", FS: os.DirFS(filepath.Join("..", "data"))}
+	tests := []struct {
+		name string
+		roots []DataRoot
+		locale string
+	}{
+		{"base without values", []DataRoot{{Name: "empty", FS: fstest.MapFS{}}}, ""},
+		{"unknown locale", []DataRoot{base}, "xx"},
+		{"unreadable mod", []DataRoot{base, {Name: "broken", FS: fstest.MapFS{
+			ScenarioFile: {Data: []byte("{")},
+		}}}, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer func() {
+				if nil == recover() {
+					t.Error("the data was loaded")
+				}
+			}()
+			LoadData(test.roots, test.locale)
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package game
+
+import (
+	"fmt"
+)
+
+// Discovery is learned on the branch where its condition first holds and is
+// known on every branch from then on. It unlocks policies and reveals values
+// that stay hidden until it is made.
+type Discovery struct {
+	ID string `json:"id"`
+	Condition *GameEndCondition `json:"condition"`
+	Text string `json:"text"`
+	UnlocksPolicies []string `json:"unlocks_policies"`
+	RevealsValues []string `json:"reveals_values"`
+}
+
+type UnknownDiscoveryError struct {
+	Discovery string
+}
+
+func (e *UnknownDiscoveryError) Error() string {
+	return fmt.Sprintf("Unknown discovery %s", e.Discovery)
+}
+
+type PolicyUndiscoveredError struct {
+	Policy string
+	Discovery string
+}
+
+func (e *PolicyUndiscoveredError) Error() string {
+	return fmt.Sprintf("Policy %s isn't discovered yet", e.Policy)
+}
+
+// Discovery returns the discovery with the id or nil.
+func (s *Scenario) Discovery(id string) *Discovery {
+	for k, v := range s.Discoveries {
+		if v.ID == id {
+			return &s.Discoveries[k]
+		}
+	}
+	return nil
+}
+
+func (d *Data) DiscoveryText(id string) string {
+	discovery := d.Scenario.Discovery(id)
+	if nil == discovery {
+		return ""
+	}
+	return d.Text("discovery."+id, discovery.Text)
+}
+
+// PolicyDiscovery returns the discovery that unlocks the policy, an empty
+// string when the policy doesn't need one.
+func (s *Scenario) PolicyDiscovery(policy string) string {
+	for _, v := range s.Discoveries {
+		for _, v2 := range v.UnlocksPolicies {
+			if v2 == policy {
+				return v.ID
+			}
+		}
+	}
+	return ""
+}
+
+// ValueDiscovery returns the discovery that reveals the value, an empty
+// string when the value is never hidden.
+func (s *Scenario) ValueDiscovery(value string) string {
+	for _, v := range s.Discoveries {
+		for _, v2 := range v.RevealsValues {
+			if v2 == value {
+				return v.ID
+			}
+		}
+	}
+	return ""
+}
+
+func (s *GameStore) Discovered(id string) bool {
+	for _, v := range s.Discoveries {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+// ValueHidden returns if the value is hidden until a discovery that isn't
+// made yet.
+func (g *Instance) ValueHidden(value string) bool {
+	discovery := g.GameData.Scenario.ValueDiscovery(value)
+	return discovery != "" && !g.GetGameStore().Discovered(discovery)
+}
+
+// NextDiscovery returns the event for the first discovery of the scenario
+// whose condition holds on the current branch and that isn't made yet, nil
+// when there is none.
+func (g *Instance) NextDiscovery() *DiscoveryEvent {
+	store := g.GetCurrentBranchStore()
+	gameStore := g.GetGameStore()
+	for _, v := range g.GameData.Scenario.Discoveries {
+		if gameStore.Discovered(v.ID) {
+			continue
+		}
+		if nil == v.Condition || !conditionHolds(store.Turn, v.Condition, store.Values) {
+			continue
+		}
+		return g.Discover(v.ID)
+	}
+	return nil
+}
+
+// DispatchDiscoveries makes every discovery triggered on the current branch.
+func (g *Instance) DispatchDiscoveries() {
+	for e := g.NextDiscovery(); nil != e; e = g.NextDiscovery() {
+		g.Dispatch(e)
+	}
+}
+
+func mergeDiscoveries(discoveries []Discovery, overlay []Discovery) []Discovery {
+	for _, v := range overlay {
+		replaced := false
+		for k, v2 := range discoveries {
+			if v2.ID == v.ID {
+				discoveries[k] = v
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			discoveries = append(discoveries, v)
+		}
+	}
+	return discoveries
+}
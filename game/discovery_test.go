@@ -0,0 +1,59 @@
+package game
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestModDiscoveryReachesOldSave(t *testing.T) {
+	base := testData(t)
+	if len(base.Scenario.Discoveries) != 0 {
+		t.Fatal("the base scenario has discoveries")
+	}
+	modded := LoadData([]DataRoot{
+		{Name: "data", FS: os.DirFS(filepath.Join("..", "data"))},
+		{Name: "storm", FS: os.DirFS(filepath.Join("..", "mods", "storm"))},
+	}, "nl")
+	if nil == modded.Scenario.Discovery("storm") {
+		t.Fatal("the storm mod has no storm discovery")
+	}
+	// The player has to live to see the storm
+	for _, v := range []*Data{base, modded} {
+		v.Scenario.LoseCondition = &GameEndCondition{Name: "health", Sign: "-", Value: -1000}
+	}
+
+	fileName := filepath.Join(t.TempDir(), "save")
+	g := newTestGame(t, fileName, base)
+	for g.GetCurrentBranchStore().Turn < 12 {
+		must(t, g.EndTurn())
+	}
+	must(t, g.Close())
+
+	g, err := NewGame(fileName, modded)
+	must(t, err)
+	defer g.Close()
+	if !g.ValueHidden("shelter") {
+		t.Fatal("shelter is shown before the storm")
+	}
+	g.DispatchDiscoveries()
+	if !g.GetGameStore().Discovered("storm") {
+		t.Fatal("the storm wasn't discovered")
+	}
+	if g.ValueHidden("shelter") {
+		t.Error("shelter is still hidden")
+	}
+}
+
+func TestDiscoveryWithUnknownValue(t *testing.T) {
+	gameData := testData(t)
+	gameData.Scenario.Discoveries = []Discovery{
+		{ID: "gold", Condition: &GameEndCondition{Name: "gold", Sign: "+", Value: 1}},
+	}
+	g := newTestGame(t, filepath.Join(t.TempDir(), "save"), gameData)
+	defer g.Close()
+	must(t, g.EndTurn())
+	if g.GetGameStore().Discovered("gold") {
+		t.Error("a condition on an unknown value held")
+	}
+}
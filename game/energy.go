@@ -0,0 +1,100 @@
+package game
+
+import (
+	"fmt"
+)
+
+// EnergyRegen adds Amount after every turn ended on a branch meeting the
+// condition, every turn when there is no condition.
+type EnergyRegen struct {
+	Amount float64 `json:"amount"`
+	Condition *GameEndCondition `json:"condition"`
+}
+
+// TemporalEnergy is the budget spent on travelling through time, it is
+// shared by every branch. Rewinding costs WindbackCost plus
+// WindbackCostPerTurn for every turn rewound, starting a branch costs
+// BranchCost plus BranchCostPerTurn for every turn it starts in the past.
+type TemporalEnergy struct {
+	Start float64 `json:"start"`
+	Max float64 `json:"max"`
+	WindbackCost float64 `json:"windback_cost"`
+	WindbackCostPerTurn float64 `json:"windback_cost_per_turn"`
+	BranchCost float64 `json:"branch_cost"`
+	BranchCostPerTurn float64 `json:"branch_cost_per_turn"`
+	Regen []EnergyRegen `json:"regen"`
+}
+
+type TemporalEnergyError struct {
+	Needed float64
+	Available float64
+}
+
+func (e *TemporalEnergyError) Error() string {
+	return fmt.Sprintf("Not enough temporal energy, %.1f needed and %.1f left", e.Needed, e.Available)
+}
+
+func (g *Instance) temporalEnergy() *TemporalEnergy {
+	return g.GameData.Scenario.TemporalEnergy
+}
+
+// WindbackCost returns the energy needed to wind the branch back from the
+// turn to the turn.
+func (g *Instance) WindbackCost(from uint64, to uint64) float64 {
+	energy := g.temporalEnergy()
+	if nil == energy || to >= from {
+		return 0
+	}
+	return energy.WindbackCost+energy.WindbackCostPerTurn*float64(from-to)
+}
+
+// BranchCost returns the energy needed to start a branch at the current,
+// possibly rewinded, turn.
+func (g *Instance) BranchCost() float64 {
+	energy := g.temporalEnergy()
+	if nil == energy {
+		return 0
+	}
+	rewound := g.GetCurrentBranchStore().Turn-g.GetRewindedBranchStore().Turn
+	return energy.BranchCost+energy.BranchCostPerTurn*float64(rewound)
+}
+
+// checkEnergy returns an error when the cost is more than the energy left.
+func (g *Instance) checkEnergy(cost float64) error {
+	if nil == g.temporalEnergy() || cost <= 0 {
+		return nil
+	}
+	available := g.GetGameStore().TemporalEnergy
+	if cost > available {
+		return &TemporalEnergyError{cost, available}
+	}
+	return nil
+}
+
+func (g *Instance) spendEnergy(cost float64, reason string) {
+	if nil == g.temporalEnergy() || cost <= 0 {
+		return
+	}
+	g.Dispatch(g.ChangeTemporalEnergy(-cost, reason))
+}
+
+// RegenerateEnergy adds the energy regenerated by the turn just ended on the
+// current branch.
+func (g *Instance) RegenerateEnergy() {
+	energy := g.temporalEnergy()
+	if nil == energy {
+		return
+	}
+	store := g.GetCurrentBranchStore()
+	amount := 0.0
+	for _, v := range energy.Regen {
+		if nil != v.Condition && !conditionHolds(store.Turn, v.Condition, store.Values) {
+			continue
+		}
+		amount += v.Amount
+	}
+	if amount == 0 {
+		return
+	}
+	g.Dispatch(g.ChangeTemporalEnergy(amount, "regen"))
+}
@@ -2,7 +2,6 @@ package game
 
 import (
 	"fmt"
-	"time"
 
 	"github.com/pkartner/event"
 )
@@ -13,17 +12,27 @@ const (
 	SetBranchEventType = "set_branch"
 	SetScreenEventType = "set_screen"
 	SetSelectedValueType = "set_selected_value"
+	SetModsEventType = "set_mods"
+	StoryBeatEventType = "story_beat"
+	SetCompareBranchEventType = "set_compare_branch"
+	SetBranchNameEventType = "set_branch_name"
+	SetBranchNoteEventType = "set_branch_note"
+	SetTurnNoteEventType = "set_turn_note"
+	SetBookmarkEventType = "set_bookmark"
+	ArchiveBranchEventType = "archive_branch"
+	DeleteBranchEventType = "delete_branch"
+	TemporalEnergyEventType = "temporal_energy"
+	DiscoveryEventType = "discovery"
+	TimelineOutcomeEventType = "timeline_outcome"
 )
 
-func SetBasicEventValues(e *event.BaseEvent) {
-	lastID := Current.Dispatcher.Store.LastEvent.ID().IDPart()
-	t := uint64(time.Now().Unix())
-	e.EventID = event.GenerateTimeID(t, lastID+1)
-	e.EventTime = t
+func (g *Instance) SetBasicEventValues(e *event.BaseEvent) {
+	e.EventID, e.EventTime = g.EventStamp()
 }
 
 type NextTurnEvent struct {
 	event.BaseTimelineEvent
+	EventSchema
 }
 
 // Type TODO
@@ -31,24 +40,24 @@ func (e *NextTurnEvent) Type() event.EventType {
     return NextTurnEventType
 }
 
-func NextTurn() *NextTurnEvent{
-	store := Current.GetCurrentBranchStore()
-	gameStore := Current.GetGameStore()
-	lastID := Current.Dispatcher.Store.LastEvent.ID().IDPart()
+func (g *Instance) NextTurn() *NextTurnEvent{
+	store := g.GetCurrentBranchStore()
+	gameStore := g.GetGameStore()
 	e := NextTurnEvent{}
-	e.EventTime = uint64(time.Now().Unix())
-	e.EventID = event.GenerateTimeID(e.EventTime, lastID+1)
+	e.EventID, e.EventTime = g.EventStamp()
 	fmt.Println(fmt.Sprintf("Next event turn time is %d", store.Turn))
 	e.BranchEventTime = store.Turn+1
 	
 	
 	fmt.Println(fmt.Sprintf("Current event turn time is %d", e.Time()))
 	e.BranchID = gameStore.CurrentBranch
+	e.Version = CurrentSchemaVersion(NextTurnEventType)
 	return &e
 }
 
 type SetPolicyEvent struct {
 	event.BaseTimelineEvent
+	EventSchema
 	Policy string
 	State bool
 }
@@ -57,24 +66,48 @@ func (e *SetPolicyEvent) Type() event.EventType {
 	return SetPolicyEventType
 }
 
-func SetPolicy(policy string) *SetPolicyEvent {
-	store := Current.GetCurrentBranchStore()
-	gameStore := Current.GetGameStore()
-	state := false
+// SetPolicy toggles the policy on the current branch.
+func (g *Instance) SetPolicy(policy string) *SetPolicyEvent {
+	store := g.GetCurrentBranchStore()
 	_, ok := store.ActivePolicies[policy]
-	if !ok {
-		state = true
-	}
-	lastID := Current.Dispatcher.Store.LastEvent.ID().IDPart()
+	return g.SetPolicyState(policy, !ok)
+}
+
+func (g *Instance) SetPolicyState(policy string, state bool) *SetPolicyEvent {
+	store := g.GetCurrentBranchStore()
+	gameStore := g.GetGameStore()
 	e := SetPolicyEvent{
 		Policy: policy,
 		State: state,
 	}
-	e.EventTime = uint64(time.Now().Unix())
-	e.EventID = event.GenerateTimeID(e.EventTime, lastID+1)
+	e.EventID, e.EventTime = g.EventStamp()
+
+	e.BranchID = gameStore.CurrentBranch
+	e.BranchEventTime = store.Turn
+	e.Version = CurrentSchemaVersion(SetPolicyEventType)
+	return &e
+}
 
+type StoryBeatEvent struct {
+	event.BaseTimelineEvent
+	EventSchema
+	Beat string
+}
+
+func (e *StoryBeatEvent) Type() event.EventType {
+	return StoryBeatEventType
+}
+
+func (g *Instance) TellStoryBeat(beat string) *StoryBeatEvent {
+	store := g.GetCurrentBranchStore()
+	gameStore := g.GetGameStore()
+	e := StoryBeatEvent{
+		Beat: beat,
+	}
+	e.EventID, e.EventTime = g.EventStamp()
 	e.BranchID = gameStore.CurrentBranch
 	e.BranchEventTime = store.Turn
+	e.Version = CurrentSchemaVersion(StoryBeatEventType)
 	return &e
 }
 
@@ -82,6 +115,7 @@ func SetPolicy(policy string) *SetPolicyEvent {
 
 type SetBranchEvent struct {
 	event.BaseEvent
+	EventSchema
 	BranchID event.ID
 }
 
@@ -89,16 +123,18 @@ func (e *SetBranchEvent) Type() event.EventType {
 	return SetBranchEventType
 }
 
-func SetBranch(branchID event.ID) *SetBranchEvent{
+func (g *Instance) SetBranch(branchID event.ID) *SetBranchEvent{
 	e := SetBranchEvent{
 		BranchID: branchID,
 	}
-	SetBasicEventValues(&e.BaseEvent)
+	g.SetBasicEventValues(&e.BaseEvent)
+	e.Version = CurrentSchemaVersion(SetBranchEventType)
 	return &e
 }
 
 type SetScreenEvent struct {
 	event.BaseEvent
+	EventSchema
 	Screen string
 }
 
@@ -106,16 +142,18 @@ func (e *SetScreenEvent) Type() event.EventType {
 	return SetScreenEventType
 }
 
-func SetScreen(screen string) *SetScreenEvent {
+func (g *Instance) SetScreen(screen string) *SetScreenEvent {
 	e := SetScreenEvent{
 		Screen: screen,
 	}
-	SetBasicEventValues(&e.BaseEvent)
+	g.SetBasicEventValues(&e.BaseEvent)
+	e.Version = CurrentSchemaVersion(SetScreenEventType)
 	return &e
 }
 
 type SetSelectedValueEvent struct {
 	event.BaseEvent
+	EventSchema
 	Value string
 }
 
@@ -123,10 +161,243 @@ func (e *SetSelectedValueEvent) Type() event.EventType {
 	return SetSelectedValueType
 }
 
-func SetSelectedValue(value string) *SetSelectedValueEvent {
+func (g *Instance) SetSelectedValue(value string) *SetSelectedValueEvent {
 		e := SetSelectedValueEvent{
 		Value: value,
 	}
-	SetBasicEventValues(&e.BaseEvent)
+	g.SetBasicEventValues(&e.BaseEvent)
+	e.Version = CurrentSchemaVersion(SetSelectedValueType)
+	return &e
+}
+
+type SetModsEvent struct {
+	event.BaseEvent
+	EventSchema
+	Mods []string
+}
+
+func (e *SetModsEvent) Type() event.EventType {
+	return SetModsEventType
+}
+
+func (g *Instance) SetMods(mods []string) *SetModsEvent {
+	e := SetModsEvent{
+		Mods: mods,
+	}
+	g.SetBasicEventValues(&e.BaseEvent)
+	e.Version = CurrentSchemaVersion(SetModsEventType)
+	return &e
+}
+
+type SetCompareBranchEvent struct {
+	event.BaseEvent
+	EventSchema
+	BranchID event.ID
+}
+
+func (e *SetCompareBranchEvent) Type() event.EventType {
+	return SetCompareBranchEventType
+}
+
+func (g *Instance) SetCompareBranch(branchID event.ID) *SetCompareBranchEvent {
+	e := SetCompareBranchEvent{
+		BranchID: branchID,
+	}
+	g.SetBasicEventValues(&e.BaseEvent)
+	e.Version = CurrentSchemaVersion(SetCompareBranchEventType)
+	return &e
+}
+type SetBranchNameEvent struct {
+	event.BaseEvent
+	EventSchema
+	BranchID event.ID
+	Name string
+}
+
+func (e *SetBranchNameEvent) Type() event.EventType {
+	return SetBranchNameEventType
+}
+
+// SetBranchName names the branch, an empty name removes it.
+func (g *Instance) SetBranchName(branchID event.ID, name string) *SetBranchNameEvent {
+	e := SetBranchNameEvent{
+		BranchID: branchID,
+		Name: name,
+	}
+	g.SetBasicEventValues(&e.BaseEvent)
+	e.Version = CurrentSchemaVersion(SetBranchNameEventType)
+	return &e
+}
+
+type SetBranchNoteEvent struct {
+	event.BaseEvent
+	EventSchema
+	BranchID event.ID
+	Note string
+}
+
+func (e *SetBranchNoteEvent) Type() event.EventType {
+	return SetBranchNoteEventType
+}
+
+// SetBranchNote attaches the note to the branch, an empty note removes it.
+func (g *Instance) SetBranchNote(branchID event.ID, note string) *SetBranchNoteEvent {
+	e := SetBranchNoteEvent{
+		BranchID: branchID,
+		Note: note,
+	}
+	g.SetBasicEventValues(&e.BaseEvent)
+	e.Version = CurrentSchemaVersion(SetBranchNoteEventType)
+	return &e
+}
+
+type SetTurnNoteEvent struct {
+	event.BaseEvent
+	EventSchema
+	Node Node
+	Note string
+}
+
+func (e *SetTurnNoteEvent) Type() event.EventType {
+	return SetTurnNoteEventType
+}
+
+// SetTurnNote attaches the note to a turn node, an empty note removes it.
+func (g *Instance) SetTurnNote(node Node, note string) *SetTurnNoteEvent {
+	e := SetTurnNoteEvent{
+		Node: node,
+		Note: note,
+	}
+	g.SetBasicEventValues(&e.BaseEvent)
+	e.Version = CurrentSchemaVersion(SetTurnNoteEventType)
 	return &e
-}
\ No newline at end of file
+}
+
+type SetBookmarkEvent struct {
+	event.BaseEvent
+	EventSchema
+	Node Node
+	State bool
+}
+
+func (e *SetBookmarkEvent) Type() event.EventType {
+	return SetBookmarkEventType
+}
+
+func (g *Instance) SetBookmark(node Node, state bool) *SetBookmarkEvent {
+	e := SetBookmarkEvent{
+		Node: node,
+		State: state,
+	}
+	g.SetBasicEventValues(&e.BaseEvent)
+	e.Version = CurrentSchemaVersion(SetBookmarkEventType)
+	return &e
+}
+
+type ArchiveBranchEvent struct {
+	event.BaseEvent
+	EventSchema
+	BranchID event.ID
+	State bool
+}
+
+func (e *ArchiveBranchEvent) Type() event.EventType {
+	return ArchiveBranchEventType
+}
+
+// ArchiveBranch hides the branch from the timeline, or shows it again.
+func (g *Instance) ArchiveBranch(branchID event.ID, state bool) *ArchiveBranchEvent {
+	e := ArchiveBranchEvent{
+		BranchID: branchID,
+		State: state,
+	}
+	g.SetBasicEventValues(&e.BaseEvent)
+	e.Version = CurrentSchemaVersion(ArchiveBranchEventType)
+	return &e
+}
+
+type DeleteBranchEvent struct {
+	event.BaseEvent
+	EventSchema
+	BranchID event.ID
+}
+
+func (e *DeleteBranchEvent) Type() event.EventType {
+	return DeleteBranchEventType
+}
+
+// DeleteBranch deletes the branch together with the branches split off
+// from it.
+func (g *Instance) DeleteBranch(branchID event.ID) *DeleteBranchEvent {
+	e := DeleteBranchEvent{
+		BranchID: branchID,
+	}
+	g.SetBasicEventValues(&e.BaseEvent)
+	e.Version = CurrentSchemaVersion(DeleteBranchEventType)
+	return &e
+}
+
+type TemporalEnergyEvent struct {
+	event.BaseEvent
+	EventSchema
+	Amount float64
+	Reason string
+}
+
+func (e *TemporalEnergyEvent) Type() event.EventType {
+	return TemporalEnergyEventType
+}
+
+// ChangeTemporalEnergy adds the amount to the temporal energy, a negative
+// amount spends it.
+func (g *Instance) ChangeTemporalEnergy(amount float64, reason string) *TemporalEnergyEvent {
+	e := TemporalEnergyEvent{
+		Amount: amount,
+		Reason: reason,
+	}
+	g.SetBasicEventValues(&e.BaseEvent)
+	e.Version = CurrentSchemaVersion(TemporalEnergyEventType)
+	return &e
+}
+
+// DiscoveryEvent makes a discovery for every branch, Node is where it was
+// made.
+type DiscoveryEvent struct {
+	event.BaseEvent
+	EventSchema
+	Discovery string
+	Node Node
+}
+
+func (e *DiscoveryEvent) Type() event.EventType {
+	return DiscoveryEventType
+}
+
+func (g *Instance) Discover(discovery string) *DiscoveryEvent {
+	e := DiscoveryEvent{
+		Discovery: discovery,
+		Node: Node{g.GetGameStore().CurrentBranch, g.GetCurrentBranchStore().Turn},
+	}
+	g.SetBasicEventValues(&e.BaseEvent)
+	e.Version = CurrentSchemaVersion(DiscoveryEventType)
+	return &e
+}
+
+type TimelineOutcomeEvent struct {
+	event.BaseEvent
+	EventSchema
+	Outcome uint8
+}
+
+func (e *TimelineOutcomeEvent) Type() event.EventType {
+	return TimelineOutcomeEventType
+}
+
+func (g *Instance) SetTimelineOutcome(outcome uint8) *TimelineOutcomeEvent {
+	e := TimelineOutcomeEvent{
+		Outcome: outcome,
+	}
+	g.SetBasicEventValues(&e.BaseEvent)
+	e.Version = CurrentSchemaVersion(TimelineOutcomeEventType)
+	return &e
+}
@@ -0,0 +1,193 @@
+package game
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pkartner/event"
+)
+
+// eventPrototypes creates an empty event for every event type that can be
+// persisted, new event types have to be added here to be importable.
+var eventPrototypes = map[event.EventType]func() event.Event{
+	"new_branch": func() event.Event { return &event.NewBranchEvent{} },
+	"windback": func() event.Event { return &event.WindbackEvent{} },
+	NextTurnEventType: func() event.Event { return &NextTurnEvent{} },
+	SetPolicyEventType: func() event.Event { return &SetPolicyEvent{} },
+	SetBranchEventType: func() event.Event { return &SetBranchEvent{} },
+	SetScreenEventType: func() event.Event { return &SetScreenEvent{} },
+	SetSelectedValueType: func() event.Event { return &SetSelectedValueEvent{} },
+	SetModsEventType: func() event.Event { return &SetModsEvent{} },
+	StoryBeatEventType: func() event.Event { return &StoryBeatEvent{} },
+	SetCompareBranchEventType: func() event.Event { return &SetCompareBranchEvent{} },
+	SetBranchNameEventType: func() event.Event { return &SetBranchNameEvent{} },
+	SetBranchNoteEventType: func() event.Event { return &SetBranchNoteEvent{} },
+	SetTurnNoteEventType: func() event.Event { return &SetTurnNoteEvent{} },
+	SetBookmarkEventType: func() event.Event { return &SetBookmarkEvent{} },
+	ArchiveBranchEventType: func() event.Event { return &ArchiveBranchEvent{} },
+	DeleteBranchEventType: func() event.Event { return &DeleteBranchEvent{} },
+	TemporalEnergyEventType: func() event.Event { return &TemporalEnergyEvent{} },
+	DiscoveryEventType: func() event.Event { return &DiscoveryEvent{} },
+	TimelineOutcomeEventType: func() event.Event { return &TimelineOutcomeEvent{} },
+}
+
+// EventLogLine is a line of an exported event log, the id, type, time and
+// branch are repeated outside the payload so the log reads and diffs well.
+type EventLogLine struct {
+	ID string `json:"id"`
+	Type event.EventType `json:"type"`
+	Time uint64 `json:"time"`
+	Branch string `json:"branch,omitempty"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+func eventBranch(e event.Event) string {
+	branches := eventBranches(e)
+	if len(branches) == 0 {
+		return ""
+	}
+	return branches[0].ToString()
+}
+
+// ExportEventLog writes every event of the game as a line of JSON.
+func (g *Instance) ExportEventLog(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	return g.EventStore.Restore(^uint64(0), func(e event.Event) error {
+		payload, err := json.Marshal(e)
+		if nil != err {
+			return err
+		}
+		return encoder.Encode(EventLogLine{
+			ID: e.ID().ToString(),
+			Type: e.Type(),
+			Time: e.Time(),
+			Branch: eventBranch(e),
+			Payload: payload,
+		})
+	})
+}
+
+type EventLogError struct {
+	Line int
+	Err error
+}
+
+func (e *EventLogError) Error() string {
+	return fmt.Sprintf("Event log line %d: %s", e.Line, e.Err.Error())
+}
+
+// ParseEventLogLine reads an event from a line of an exported event log and
+// checks the line agrees with its payload.
+func ParseEventLogLine(data []byte) (event.Event, error) {
+	line := EventLogLine{}
+	if err := json.Unmarshal(data, &line); nil != err {
+		return nil, err
+	}
+	e, err := DecodeEvent(line.Type, line.Payload)
+	if nil != err {
+		return nil, err
+	}
+	if e.ID().ToString() != line.ID {
+		return nil, fmt.Errorf("Event id %s doesn't match the payload id %s", line.ID, e.ID().ToString())
+	}
+	if e.ID() == event.ZeroID() {
+		return nil, fmt.Errorf("Event has no id")
+	}
+	if e.Time() != line.Time {
+		return nil, fmt.Errorf("Event time %d doesn't match the payload time %d", line.Time, e.Time())
+	}
+	if eventBranch(e) != line.Branch {
+		return nil, fmt.Errorf("Event branch %s doesn't match the payload branch %s", line.Branch, eventBranch(e))
+	}
+	return e, nil
+}
+
+// ImportEventLog creates a new save from an exported event log. Every event
+// is validated against the game built from the events before it, the save
+// is removed again when the log is rejected.
+func ImportEventLog(fileName string, gameData *Data, r io.Reader) error {
+	if _, err := os.Stat(fileName+".db"); !os.IsNotExist(err) {
+		return fmt.Errorf("Save %s already exists", fileName)
+	}
+	g, err := NewGame(fileName, gameData)
+	if nil != err {
+		os.Remove(fileName+".db")
+		return err
+	}
+	err = g.importEventLog(r)
+	if closeErr := g.Close(); nil == err {
+		err = closeErr
+	}
+	if nil != err {
+		os.Remove(fileName+".db")
+	}
+	return err
+}
+
+func (g *Instance) importEventLog(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	seen := map[event.ID]struct{}{}
+	lastTime := uint64(0)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		e, err := ParseEventLogLine(scanner.Bytes())
+		if nil != err {
+			return &EventLogError{lineNumber, err}
+		}
+		if _, ok := seen[e.ID()]; ok {
+			return &EventLogError{lineNumber, fmt.Errorf("Duplicate event id %s", e.ID().ToString())}
+		}
+		seen[e.ID()] = struct{}{}
+		if e.Time() < lastTime {
+			return &EventLogError{lineNumber, fmt.Errorf("Event time %d is before the previous event", e.Time())}
+		}
+		lastTime = e.Time()
+		if err := g.validateImported(e); nil != err {
+			return &EventLogError{lineNumber, err}
+		}
+		g.Dispatch(e)
+	}
+	return scanner.Err()
+}
+
+// validateImported checks the event could have been made in the game as it
+// is, on top of the validation of player actions.
+func (g *Instance) validateImported(e event.Event) error {
+	gameStore := g.GetGameStore()
+	switch e := e.(type) {
+	case *event.NewBranchEvent:
+		if _, ok := g.GetTimeLineStore().BranchDictionary[e.NewBranchID]; ok {
+			return fmt.Errorf("Branch %s already exists", e.NewBranchID.ToString())
+		}
+		if len(g.GetTimeLineStore().Branches) > 0 {
+			if _, err := g.branchStore(gameStore.CurrentBranch); nil != err {
+				return err
+			}
+		}
+		return nil
+	case *event.WindbackEvent:
+		_, err := g.branchStore(gameStore.CurrentBranch)
+		return err
+	case *NextTurnEvent:
+		if e.BranchID != gameStore.CurrentBranch {
+			return fmt.Errorf("Turn ended on %s while on branch %s", e.BranchID.ToString(), gameStore.CurrentBranch.ToString())
+		}
+	case *SetPolicyEvent:
+		if e.BranchID != gameStore.CurrentBranch {
+			return fmt.Errorf("Policy set on %s while on branch %s", e.BranchID.ToString(), gameStore.CurrentBranch.ToString())
+		}
+	case *StoryBeatEvent:
+		if _, err := g.branchStore(e.BranchID); nil != err {
+			return err
+		}
+	}
+	return g.Validate(e)
+}
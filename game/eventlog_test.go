@@ -0,0 +1,101 @@
+package game
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEventLogRoundTrip(t *testing.T) {
+	gameData := testData(t)
+	log := playLoggedTestGame(t, gameData)
+	fileName := filepath.Join(t.TempDir(), "imported")
+	must(t, ImportEventLog(fileName, gameData, bytes.NewReader(log)))
+
+	g, err := NewGame(fileName, gameData)
+	must(t, err)
+	defer g.Close()
+	exported := bytes.Buffer{}
+	must(t, g.ExportEventLog(&exported))
+	if !bytes.Equal(exported.Bytes(), log) {
+		t.Fatalf("the imported save exports a different log:\n%s\n%s", exported.Bytes(), log)
+	}
+	if g.Chain.Modified {
+		t.Error("the imported save is marked as modified")
+	}
+	if len(g.GetTimeLineStore().Branches) != 2 || g.GetCurrentBranchStore().Turn != 6 {
+		t.Errorf("got %d branches at turn %d, want 2 at turn 6", len(g.GetTimeLineStore().Branches), g.GetCurrentBranchStore().Turn)
+	}
+
+	if err := ImportEventLog(fileName, gameData, bytes.NewReader(log)); nil == err {
+		t.Error("the log was imported over an existing save")
+	}
+}
+
+func TestEventLogImportRejects(t *testing.T) {
+	gameData := testData(t)
+	log := strings.Split(strings.TrimSpace(string(playLoggedTestGame(t, gameData))), "\n")
+	// The log starts with the root branch, setting it, a turn and a story
+	// beat, a regen of temporal energy and another turn
+	policyLine := 0
+	for k, v := range log {
+		if strings.Contains(v, `"Policy":"collect food"`) {
+			policyLine = k
+			break
+		}
+	}
+	if policyLine == 0 {
+		t.Fatal("the log sets no policy")
+	}
+	tests := []struct {
+		name string
+		edit func(lines []string) []string
+		line int
+	}{
+		{"malformed line", func(lines []string) []string {
+			lines[2] = "{"
+			return lines
+		}, 3},
+		{"unknown type", func(lines []string) []string {
+			lines[2] = strings.Replace(lines[2], `"type":"next_turn"`, `"type":"no_such_event"`, 1)
+			return lines
+		}, 3},
+		{"id not in payload", func(lines []string) []string {
+			lines[2] = strings.Replace(lines[2], `"id":"00000000000000030000000000000003"`, `"id":"00000000000000030000000000000009"`, 1)
+			return lines
+		}, 3},
+		{"duplicate event", func(lines []string) []string {
+			return append(lines[:2], append([]string{lines[1]}, lines[2:]...)...)
+		}, 3},
+		{"time going back", func(lines []string) []string {
+			lines[4], lines[5] = lines[5], lines[4]
+			return lines
+		}, 6},
+		{"turn on another branch", func(lines []string) []string {
+			return append(lines[:1], lines[2:]...)
+		}, 2},
+		{"unknown policy", func(lines []string) []string {
+			lines[policyLine] = strings.Replace(lines[policyLine], `"Policy":"collect food"`, `"Policy":"gold"`, 1)
+			return lines
+		}, policyLine+1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			lines := test.edit(append([]string{}, log...))
+			fileName := filepath.Join(t.TempDir(), "imported")
+			err := ImportEventLog(fileName, gameData, strings.NewReader(strings.Join(lines, "\n")))
+			logErr, ok := err.(*EventLogError)
+			if !ok {
+				t.Fatalf("got error %v, want an event log error", err)
+			}
+			if logErr.Line != test.line {
+				t.Errorf("rejected line %d, want %d: %s", logErr.Line, test.line, logErr)
+			}
+			if _, err := os.Stat(fileName+".db"); !os.IsNotExist(err) {
+				t.Error("the rejected save was kept")
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package game
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+
+	"github.com/boltdb/bolt"
+	"github.com/pkartner/event"
+)
+
+var eventBucket = []byte("game_events")
+
+// storedEvent is an event as it is kept in the save. The payload stays raw
+// JSON until it is upcast to the current schema version, so upcasters can
+// read fields the current event structs no longer have.
+type storedEvent struct {
+	Type event.EventType `json:"type"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+func eventKey(sequence uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, sequence)
+	return key
+}
+
+// putEvent appends the event to the events of the save.
+func putEvent(tx *bolt.Tx, stored storedEvent) error {
+	bucket, err := tx.CreateBucketIfNotExists(eventBucket)
+	if nil != err {
+		return err
+	}
+	sequence, err := bucket.NextSequence()
+	if nil != err {
+		return err
+	}
+	data, err := json.Marshal(stored)
+	if nil != err {
+		return err
+	}
+	return bucket.Put(eventKey(sequence), data)
+}
+
+// readEvents hands out the stored events of the save with their sequence
+// number, in the order they were added.
+func readEvents(db *bolt.DB, handle func(uint64, storedEvent) error) error {
+	return db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(eventBucket)
+		if nil == bucket {
+			return nil
+		}
+		cursor := bucket.Cursor()
+		for k, v := cursor.First(); k != nil; k, v = cursor.Next() {
+			stored := storedEvent{}
+			if err := json.Unmarshal(v, &stored); nil != err {
+				return fmt.Errorf("Could not read event %d: %s", binary.BigEndian.Uint64(k), err)
+			}
+			if err := handle(binary.BigEndian.Uint64(k), stored); nil != err {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// DecodeEvent upcasts the payload to the current version of the event type
+// and decodes it.
+func DecodeEvent(eventType event.EventType, payload []byte) (event.Event, error) {
+	prototype, ok := eventPrototypes[eventType]
+	if !ok {
+		return nil, fmt.Errorf("Unknown event type %s", eventType)
+	}
+	upcasted, err := Upcast(eventType, payload)
+	if nil != err {
+		return nil, err
+	}
+	e := prototype()
+	if err := json.Unmarshal(upcasted, e); nil != err {
+		return nil, fmt.Errorf("Could not decode event %s: %s", eventType, err)
+	}
+	return e, nil
+}
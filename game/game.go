@@ -11,8 +11,6 @@ import (
 type ValueMap map[string]float64
 type WeightMap map[string]map[string]Weight
 
-var Current *Instance
-
 type MaxMin struct {
 	Set bool `json:"set"`
 	Value float64 `json:"value"`
@@ -27,6 +25,7 @@ type Weight struct {
 type Values struct {
 	Values map[string]struct {
 		Name string `json:"name"`
+		Description string `json:"description"`
 		NaturalChange float64 `json:"natural_change"`
 		Min MaxMin `json:"min"`
 		Max MaxMin `json:"max"`
@@ -43,6 +42,7 @@ type Values struct {
 type Policies struct {
 	Policies map[string]struct {
 		Name string `json:"name"`
+		Description string `json:"description"`
 		FlatAmountPerTurn []struct {
 			ValueName string `json:"value_name"`
 			Amount float64 `json:"amount"`
@@ -55,7 +55,7 @@ type Policies struct {
 		Restrictions []struct {
 			ValueName string `json:"value_name"`
 			Amount float64 `json:"amount"`
-		}
+		} `json:"restrictions"`
 	} `json:"policies"`
 	MutualExclusive [][]string `json:"mutual_exclusive"`
 }
@@ -66,22 +66,92 @@ type GameEndCondition struct {
 	Value float64 `json:"value"`
 }
 
+// PolicyLock forces a policy on or off from FromTurn until, but not
+// including, UntilTurn. An UntilTurn of 0 keeps the lock forever.
+type PolicyLock struct {
+	Policy string `json:"policy"`
+	State bool `json:"state"`
+	FromTurn uint64 `json:"from_turn"`
+	UntilTurn uint64 `json:"until_turn"`
+	Reason string `json:"reason"`
+}
+
+func (l *PolicyLock) Active(turn uint64) bool {
+	if turn < l.FromTurn {
+		return false
+	}
+	return l.UntilTurn == 0 || turn < l.UntilTurn
+}
+
 type Scenario struct {
+	Name string `json:"name"`
 	StartValues ValueMap `json:"start_values"`
 	WinCondition *GameEndCondition `json:"win_condition"`
 	LoseCondition *GameEndCondition `json:"lose_condition"`
+	AvailablePolicies []string `json:"available_policies"`
+	StartPolicies []string `json:"start_policies"`
+	LockedPolicies []PolicyLock `json:"locked_policies"`
+	Intro *Intro `json:"intro"`
+	Story []StoryBeat `json:"story"`
+	TemporalEnergy *TemporalEnergy `json:"temporal_energy"`
+	Discoveries []Discovery `json:"discoveries"`
+	TimelineWin *TimelineObjective `json:"timeline_win"`
+	TimelineLose *TimelineObjective `json:"timeline_lose"`
+}
+
+// PolicyAvailable reports if the scenario offers the policy, all policies
+// are offered when the scenario doesn't list any.
+func (s *Scenario) PolicyAvailable(policy string) bool {
+	if len(s.AvailablePolicies) == 0 {
+		return true
+	}
+	for _, v := range s.AvailablePolicies {
+		if v == policy {
+			return true
+		}
+	}
+	return false
+}
+
+// PolicyLock returns the lock on the policy for the turn or nil.
+func (s *Scenario) PolicyLock(policy string, turn uint64) *PolicyLock {
+	for k, v := range s.LockedPolicies {
+		if v.Policy == policy && v.Active(turn) {
+			return &s.LockedPolicies[k]
+		}
+	}
+	return nil
 }
 
 type Instance struct {
 	GameData *Data
 	EventStore event.EventStore
 	Dispatcher *event.TimelineDispatcher
+	Clock Clock
+	IDs IDSource
+	DB *bolt.DB
+	Chain *ChainedEventStore
+	// SnapshotInterval is the number of events between snapshots of the
+	// state, 0 turns snapshots off.
+	SnapshotInterval uint64
+	// ForbidRewindBranching refuses changes while the current branch is
+	// rewound instead of moving them onto a new branch.
+	ForbidRewindBranching bool
+	eventCount uint64
+	projections []Projection
 }
 
 type Data struct {
-	Values Values
-	Policies Policies
-	Scenario Scenario
+	Values Values `json:"values"`
+	Policies Policies `json:"policies"`
+	Scenario Scenario `json:"scenario"`
+	Achievements Achievements `json:"achievements"`
+	Mods []string `json:"mods"`
+	Locale Locale `json:"locale"`
+}
+
+func (g *Instance) Close() error {
+	return g.DB.Close()
 }
 
 func (g *Instance) Restore() {
@@ -112,6 +182,55 @@ func CalculateWeightMap(policies Policies, values Values, activatedPolicies map[
 	return weightMap
 }
 
+// MutualExclusivePolicies returns the policies that are turned off when the
+// policy is turned on.
+func MutualExclusivePolicies(policies Policies, policy string) []string {
+	exclusive := []string{}
+	for _, v := range policies.MutualExclusive {
+		present := false
+		for _, v2 := range v {
+			if v2 == policy {
+				present = true
+				break
+			}
+		}
+		if !present {
+			continue
+		}
+		for _, v2 := range v {
+			if v2 != policy {
+				exclusive = append(exclusive, v2)
+			}
+		}
+	}
+	return exclusive
+}
+
+// ActivatePolicy adds the policy to the active policies and removes every
+// policy that is mutual exclusive with it.
+func ActivatePolicy(activePolicies map[string]struct{}, policies Policies, policy string) {
+	for _, v := range MutualExclusivePolicies(policies, policy) {
+		delete(activePolicies, v)
+	}
+
+	activePolicies[policy] = struct{}{}
+}
+
+// ApplyPolicyLocks forces the locked policies of the scenario into the
+// state they are locked in for the turn.
+func ApplyPolicyLocks(activePolicies map[string]struct{}, policies Policies, scenario *Scenario, turn uint64) {
+	for _, v := range scenario.LockedPolicies {
+		if !v.Active(turn) {
+			continue
+		}
+		if !v.State {
+			delete(activePolicies, v.Policy)
+			continue
+		}
+		ActivatePolicy(activePolicies, policies, v.Policy)
+	}
+}
+
 func ReEvaluatePolicies(activePolicies map[string]struct{}, policies Policies, values ValueMap) map[string]struct{} {
 	removePolicies := []string{}
 	for k := range activePolicies {
@@ -209,45 +328,109 @@ func CalculateAddedValue(key string, values ValueMap, weights WeightMap, policie
 	return adjustment
 }
 
-func NewGame(fileName string, GameData *Data) {
+// NewGame opens the save and restores it into a new instance, instances
+// don't share state so several games can run side by side. Loading starts
+// from the latest valid snapshot, the projections are restored from it or
+// rebuilt from the whole event log.
+func NewGame(fileName string, GameData *Data, projections ...Projection) (*Instance, error) {
+	return loadGame(fileName, GameData, projections, true)
+}
+
+// ReplayGame opens the save and handles every event again, ignoring the
+// snapshots.
+func ReplayGame(fileName string, GameData *Data, projections ...Projection) (*Instance, error) {
+	return loadGame(fileName, GameData, projections, false)
+}
+
+// loadGame opens and restores the save, the save is closed again when it
+// can't be restored.
+func loadGame(fileName string, GameData *Data, projections []Projection, useSnapshots bool) (*Instance, error) {
+	g, err := openGame(fileName, GameData, projections)
+	if nil != err {
+		return nil, err
+	}
+	if err := g.load(useSnapshots); nil != err {
+		g.Close()
+		return nil, fmt.Errorf("Could not load %s: %s", fileName, err)
+	}
+	return g, nil
+}
+
+func openGame(fileName string, GameData *Data, projections []Projection) (*Instance, error) {
+	names := map[string]struct{}{}
+	for _, v := range projections {
+		if _, ok := names[v.Name()]; ok {
+			return nil, fmt.Errorf("Projection %s registered twice", v.Name())
+		}
+		names[v.Name()] = struct{}{}
+	}
 	databaseFileName := fileName+".db"
     db, err := bolt.Open(databaseFileName, 0600, nil)
     if nil != err {
-        panic(err)
+        return nil, err
     }
-    eventStore := event.NewBoltEventStore(db)
-    timeStore := event.NewTimelineStore(NewBranchStoreFunc(GameData.Scenario.StartValues), event.Reloader{
+	g := newInstance(db, GameData, projections)
+	// The library decodes the events of old saves through the registered
+	// handlers
+	if err := g.Chain.MigrateLegacy(event.NewBoltEventStore(db)); nil != err {
+		db.Close()
+		return nil, fmt.Errorf("Could not migrate %s: %s", fileName, err)
+	}
+
+	return g, nil
+}
+
+// newInstance sets up the stores and handlers of a game on the opened save,
+// nothing is read from the save yet.
+func newInstance(db *bolt.DB, GameData *Data, projections []Projection) *Instance {
+	eventStore := NewChainedEventStore(db)
+    timeStore := event.NewTimelineStore(NewBranchStoreFunc(GameData), event.Reloader{
         EventStore: eventStore,
     }, nil)
-	timeStore.Attributes = &GameStore{}
+	gameStore := NewGameStore()
+	if nil != GameData.Scenario.TemporalEnergy {
+		gameStore.TemporalEnergy = GameData.Scenario.TemporalEnergy.Start
+	}
+	timeStore.Attributes = gameStore
     dispatcher := event.NewTimelineDispatcher(timeStore)
     dispatcher.SetMiddleware(
         event.EventStoreMiddleware(eventStore),
     )
 
-	Current = &Instance{
+	g := &Instance{
 		GameData: GameData,
 		Dispatcher: dispatcher,
 		EventStore: eventStore,
+		Clock: SystemClock{},
+		IDs: &SequenceIDSource{Dispatcher: dispatcher},
+		DB: db,
+		Chain: eventStore,
+		SnapshotInterval: DefaultSnapshotInterval,
+		projections: projections,
 	}
 
-    dispatcher.Dispatcher.Register(&event.WindbackEvent{}, Current.WindbackHandler)
+    dispatcher.Dispatcher.Register(&event.WindbackEvent{}, g.WindbackHandler)
     dispatcher.Dispatcher.Register(&event.NewBranchEvent{}, dispatcher.NewBranchHandler)
-    dispatcher.Dispatcher.Register(&SetBranchEvent{}, Current.SetBranchHandler)
-	dispatcher.Dispatcher.Register(&SetScreenEvent{}, Current.SetScreenHandler)
-	dispatcher.Dispatcher.Register(&SetSelectedValueEvent{}, Current.SetSelectedValueHandler)
-
-	dispatcher.Register(&NextTurnEvent{}, Current.NextTurnHandler)
-    dispatcher.Register(&SetPolicyEvent{}, Current.SetPolicyHandler)
-	eventStore.PrintAllEvents()
-	eventStore.Restore(^uint64(0),func(e event.Event) error {
-		fmt.Println(fmt.Sprintf("Loading back event with time %d and type %s", e.Time(), e.Type()))
-		if err := dispatcher.Handle(e); nil != err {
-			panic(err)
-		}
-		return nil
-	})
-	
+	dispatcher.Dispatcher.Register(&ArchiveBranchEvent{}, g.ArchiveBranchHandler)
+	dispatcher.Dispatcher.Register(&DeleteBranchEvent{}, g.DeleteBranchHandler)
+	dispatcher.Dispatcher.Register(&TemporalEnergyEvent{}, g.TemporalEnergyHandler)
+	dispatcher.Dispatcher.Register(&DiscoveryEvent{}, g.DiscoveryHandler)
+	dispatcher.Dispatcher.Register(&TimelineOutcomeEvent{}, g.TimelineOutcomeHandler)
+    dispatcher.Dispatcher.Register(&SetBranchEvent{}, g.SetBranchHandler)
+	dispatcher.Dispatcher.Register(&SetScreenEvent{}, g.SetScreenHandler)
+	dispatcher.Dispatcher.Register(&SetSelectedValueEvent{}, g.SetSelectedValueHandler)
+	dispatcher.Dispatcher.Register(&SetModsEvent{}, g.SetModsHandler)
+	dispatcher.Dispatcher.Register(&SetCompareBranchEvent{}, g.SetCompareBranchHandler)
+	dispatcher.Dispatcher.Register(&SetBranchNameEvent{}, g.SetBranchNameHandler)
+	dispatcher.Dispatcher.Register(&SetBranchNoteEvent{}, g.SetBranchNoteHandler)
+	dispatcher.Dispatcher.Register(&SetTurnNoteEvent{}, g.SetTurnNoteHandler)
+	dispatcher.Dispatcher.Register(&SetBookmarkEvent{}, g.SetBookmarkHandler)
+
+	dispatcher.Register(&NextTurnEvent{}, g.NextTurnHandler)
+    dispatcher.Register(&SetPolicyEvent{}, g.SetPolicyHandler)
+	dispatcher.Register(&StoryBeatEvent{}, g.StoryBeatHandler)
+
+	return g
 }
 
 func (g *Instance) GetTimeLineStore() *event.TimelineStore{
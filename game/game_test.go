@@ -0,0 +1,156 @@
+package game
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestInstancesRunSideBySide(t *testing.T) {
+	gameData := testData(t)
+	startValues := fmt.Sprint(gameData.Scenario.StartValues)
+	dir := t.TempDir()
+	games := make([]*Instance, 2)
+	t.Run("play", func(t *testing.T) {
+		for k := range games {
+			k := k
+			t.Run(fmt.Sprint(k), func(t *testing.T) {
+				t.Parallel()
+				g := newTestGame(t, filepath.Join(dir, fmt.Sprint(k)), gameData)
+				games[k] = g
+				values := g.GetCurrentBranchStore().Values
+				if reflect.ValueOf(values).Pointer() == reflect.ValueOf(gameData.Scenario.StartValues).Pointer() {
+					t.Error("the branch shares its values with the scenario")
+				}
+				if k == 0 {
+					playTestGame(t, g)
+					return
+				}
+				for i := 0; i < 2; i++ {
+					must(t, g.EndTurn())
+				}
+			})
+		}
+	})
+	for _, v := range games {
+		if nil != v {
+			defer v.Close()
+		}
+	}
+	if t.Failed() {
+		return
+	}
+
+	if games[0].DB == games[1].DB || games[0].Chain == games[1].Chain {
+		t.Error("the games share a save")
+	}
+	if games[0].GetGameStore() == games[1].GetGameStore() {
+		t.Error("the games share a game store")
+	}
+	tests := []struct {
+		game *Instance
+		branches int
+		turn uint64
+	}{
+		{games[0], 2, 6},
+		{games[1], 1, 2},
+	}
+	for k, test := range tests {
+		if got := len(test.game.GetTimeLineStore().Branches); got != test.branches {
+			t.Errorf("game %d has %d branches, want %d", k, got, test.branches)
+		}
+		if got := test.game.GetCurrentBranchStore().Turn; got != test.turn {
+			t.Errorf("game %d is at turn %d, want %d", k, got, test.turn)
+		}
+	}
+	if fmt.Sprint(gameData.Scenario.StartValues) != startValues {
+		t.Errorf("the start values changed to %v", gameData.Scenario.StartValues)
+	}
+}
+
+func TestPolicyLockActive(t *testing.T) {
+	tests := []struct {
+		lock PolicyLock
+		turn uint64
+		active bool
+	}{
+		{PolicyLock{FromTurn: 2, UntilTurn: 4}, 1, false},
+		{PolicyLock{FromTurn: 2, UntilTurn: 4}, 2, true},
+		{PolicyLock{FromTurn: 2, UntilTurn: 4}, 3, true},
+		{PolicyLock{FromTurn: 2, UntilTurn: 4}, 4, false},
+		{PolicyLock{FromTurn: 2}, 100, true},
+	}
+	for _, test := range tests {
+		if test.lock.Active(test.turn) != test.active {
+			t.Errorf("lock from %d until %d at turn %d, want active %t", test.lock.FromTurn, test.lock.UntilTurn, test.turn, test.active)
+		}
+	}
+}
+
+func TestPolicyLocks(t *testing.T) {
+	gameData := testData(t)
+	// The test data locks rest on until turn 3
+	gameData.Scenario.LockedPolicies = append(gameData.Scenario.LockedPolicies, PolicyLock{
+		Policy: "collect food",
+		FromTurn: 4,
+		UntilTurn: 6,
+	})
+	gameData.Scenario.AvailablePolicies = []string{"rest", "collect food", "collect resources"}
+	g := newTestGame(t, filepath.Join(t.TempDir(), "save"), gameData)
+	defer g.Close()
+	policies := func() []string {
+		return g.GetCurrentBranchStore().turnState().ActivePolicies
+	}
+	if !reflect.DeepEqual(policies(), []string{"rest"}) {
+		t.Fatalf("started with %v, want rest", policies())
+	}
+
+	tests := []struct {
+		name string
+		turn uint64
+		policy string
+		err error
+	}{
+		{"locked on", 0, "rest", &PolicyLockedError{}},
+		{"exclusive with locked", 0, "collect food", &PolicyLockedError{}},
+		{"not available", 0, "build shelter", &PolicyUnavailableError{}},
+		{"unlocked", 3, "collect food", nil},
+		{"locked off", 4, "collect food", &PolicyLockedError{}},
+		{"locked off turned on", 4, "collect resources", nil},
+		{"unlocked again", 6, "collect food", nil},
+	}
+	for _, test := range tests {
+		for g.GetCurrentBranchStore().Turn < test.turn {
+			must(t, g.EndTurn())
+		}
+		err := g.ChangePolicy(test.policy)
+		if nil == test.err {
+			if nil != err {
+				t.Errorf("%s: %s", test.name, err)
+			}
+			continue
+		}
+		if nil == err || reflect.TypeOf(err) != reflect.TypeOf(test.err) {
+			t.Errorf("%s: got error %v, want %T", test.name, err, test.err)
+		}
+	}
+}
+
+func TestPolicyLockForcesState(t *testing.T) {
+	gameData := testData(t)
+	gameData.Scenario.LockedPolicies = append(gameData.Scenario.LockedPolicies, PolicyLock{
+		Policy: "collect food",
+		FromTurn: 4,
+	})
+	g := newTestGame(t, filepath.Join(t.TempDir(), "save"), gameData)
+	defer g.Close()
+	for i := 0; i < 3; i++ {
+		must(t, g.EndTurn())
+	}
+	must(t, g.ChangePolicy("collect food"))
+	must(t, g.EndTurn())
+	if policies := g.GetCurrentBranchStore().turnState().ActivePolicies; len(policies) != 0 {
+		t.Errorf("got %v at turn 4, want collect food locked off", policies)
+	}
+}
@@ -0,0 +1,324 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+const (
+	GraphValueNode = "value"
+	GraphPolicyNode = "policy"
+
+	GraphAffectsEdge = "affects"
+	GraphFlatEdge = "flat"
+	GraphWeightChangeEdge = "weight_change"
+	GraphRestrictionEdge = "restriction"
+)
+
+type GraphNode struct {
+	ID string `json:"id"`
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+	NaturalChange float64 `json:"natural_change,omitempty"`
+	Min *float64 `json:"min,omitempty"`
+	Max *float64 `json:"max,omitempty"`
+	Unaffected bool `json:"unaffected,omitempty"`
+}
+
+// GraphEdge points from the influencing node to the influenced node. For
+// weight changes Source names the value whose weight on the destination is
+// changed, for restrictions Weight is the minimum amount required.
+type GraphEdge struct {
+	From string `json:"from"`
+	To string `json:"to"`
+	Kind string `json:"kind"`
+	Weight float64 `json:"weight"`
+	Source string `json:"source,omitempty"`
+	Min *float64 `json:"min,omitempty"`
+	Max *float64 `json:"max,omitempty"`
+	Loop bool `json:"loop,omitempty"`
+}
+
+type Graph struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+	FeedbackLoops [][]string `json:"feedback_loops"`
+	Unaffected []string `json:"unaffected"`
+}
+
+func ValueNodeID(name string) string {
+	return GraphValueNode+":"+name
+}
+
+func PolicyNodeID(name string) string {
+	return GraphPolicyNode+":"+name
+}
+
+func clampValue(m MaxMin) *float64 {
+	if !m.Set {
+		return nil
+	}
+	value := m.Value
+	return &value
+}
+
+// BuildGraph collects how values and policies influence each other. Value to
+// value influences that form a cycle are reported as feedback loops, values
+// that only influence themselves count as a loop as well.
+func BuildGraph(d *Data) *Graph {
+	graph := Graph{
+		FeedbackLoops: [][]string{},
+		Unaffected: []string{},
+	}
+	valueNames := []string{}
+	for k := range d.Values.Values {
+		valueNames = append(valueNames, k)
+	}
+	sort.Strings(valueNames)
+	policyNames := []string{}
+	for k := range d.Policies.Policies {
+		policyNames = append(policyNames, k)
+	}
+	sort.Strings(policyNames)
+
+	for _, v := range valueNames {
+		value := d.Values.Values[v]
+		graph.Nodes = append(graph.Nodes, GraphNode{
+			ID: ValueNodeID(v),
+			Name: d.ValueName(v),
+			Kind: GraphValueNode,
+			NaturalChange: value.NaturalChange,
+			Min: clampValue(value.Min),
+			Max: clampValue(value.Max),
+		})
+		for _, v2 := range value.AffectedBy {
+			graph.Edges = append(graph.Edges, GraphEdge{
+				From: ValueNodeID(v2.Name),
+				To: ValueNodeID(v),
+				Kind: GraphAffectsEdge,
+				Weight: v2.Weight,
+				Min: clampValue(v2.Min),
+				Max: clampValue(v2.Max),
+			})
+		}
+	}
+	for _, v := range policyNames {
+		policy := d.Policies.Policies[v]
+		graph.Nodes = append(graph.Nodes, GraphNode{
+			ID: PolicyNodeID(v),
+			Name: d.PolicyName(v),
+			Kind: GraphPolicyNode,
+		})
+		for _, v2 := range policy.FlatAmountPerTurn {
+			graph.Edges = append(graph.Edges, GraphEdge{
+				From: PolicyNodeID(v),
+				To: ValueNodeID(v2.ValueName),
+				Kind: GraphFlatEdge,
+				Weight: v2.Amount,
+			})
+		}
+		for _, v2 := range policy.WeightChange {
+			graph.Edges = append(graph.Edges, GraphEdge{
+				From: PolicyNodeID(v),
+				To: ValueNodeID(v2.DestValueName),
+				Kind: GraphWeightChangeEdge,
+				Weight: v2.Weight,
+				Source: v2.SourceValueName,
+			})
+		}
+		for _, v2 := range policy.Restrictions {
+			graph.Edges = append(graph.Edges, GraphEdge{
+				From: ValueNodeID(v2.ValueName),
+				To: PolicyNodeID(v),
+				Kind: GraphRestrictionEdge,
+				Weight: v2.Amount,
+			})
+		}
+	}
+
+	graph.FeedbackLoops = feedbackLoops(valueNames, graph.Edges)
+	inLoop := map[string]int{}
+	for k, v := range graph.FeedbackLoops {
+		for _, v2 := range v {
+			inLoop[ValueNodeID(v2)] = k
+		}
+	}
+	for k, v := range graph.Edges {
+		if v.Kind != GraphAffectsEdge {
+			continue
+		}
+		fromLoop, ok := inLoop[v.From]
+		if !ok {
+			continue
+		}
+		toLoop, ok := inLoop[v.To]
+		if ok && fromLoop == toLoop {
+			graph.Edges[k].Loop = true
+		}
+	}
+
+	affected := map[string]struct{}{}
+	for _, v := range graph.Edges {
+		if v.Kind == GraphRestrictionEdge || v.From == v.To {
+			continue
+		}
+		affected[v.To] = struct{}{}
+	}
+	for k, v := range graph.Nodes {
+		if v.Kind != GraphValueNode {
+			continue
+		}
+		if _, ok := affected[v.ID]; ok {
+			continue
+		}
+		graph.Nodes[k].Unaffected = true
+		graph.Unaffected = append(graph.Unaffected, strings.TrimPrefix(v.ID, GraphValueNode+":"))
+	}
+
+	return &graph
+}
+
+// feedbackLoops finds the strongly connected components of the value to
+// value influences, using Tarjan's algorithm.
+func feedbackLoops(valueNames []string, edges []GraphEdge) [][]string {
+	next := map[string][]string{}
+	selfLoop := map[string]bool{}
+	for _, v := range edges {
+		if v.Kind != GraphAffectsEdge {
+			continue
+		}
+		from := strings.TrimPrefix(v.From, GraphValueNode+":")
+		to := strings.TrimPrefix(v.To, GraphValueNode+":")
+		if from == to {
+			selfLoop[from] = true
+		}
+		next[from] = append(next[from], to)
+	}
+
+	loops := [][]string{}
+	index := map[string]int{}
+	lowLink := map[string]int{}
+	onStack := map[string]bool{}
+	stack := []string{}
+	counter := 0
+	var connect func(string)
+	connect = func(value string) {
+		index[value] = counter
+		lowLink[value] = counter
+		counter++
+		stack = append(stack, value)
+		onStack[value] = true
+		for _, v := range next[value] {
+			if _, ok := index[v]; !ok {
+				connect(v)
+				if lowLink[v] < lowLink[value] {
+					lowLink[value] = lowLink[v]
+				}
+			} else if onStack[v] && index[v] < lowLink[value] {
+				lowLink[value] = index[v]
+			}
+		}
+		if lowLink[value] != index[value] {
+			return
+		}
+		component := []string{}
+		for {
+			v := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[v] = false
+			component = append(component, v)
+			if v == value {
+				break
+			}
+		}
+		if len(component) > 1 || selfLoop[value] {
+			sort.Strings(component)
+			loops = append(loops, component)
+		}
+	}
+	for _, v := range valueNames {
+		if _, ok := index[v]; !ok {
+			connect(v)
+		}
+	}
+
+	return loops
+}
+
+func (g *Graph) WriteJSON(w io.Writer) error {
+	data, err := json.MarshalIndent(g, "", "    ")
+	if nil != err {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+func (g *Graph) WriteDOT(w io.Writer) error {
+	lines := []string{"digraph timeline {"}
+	for _, v := range g.FeedbackLoops {
+		lines = append(lines, fmt.Sprintf("\t// feedback loop: %s", strings.Join(v, " -> ")))
+	}
+	for _, v := range g.Unaffected {
+		lines = append(lines, fmt.Sprintf("\t// unaffected value: %s", v))
+	}
+	for _, v := range g.Nodes {
+		label := v.Name
+		if v.NaturalChange != 0 {
+			label += fmt.Sprintf("\n%+g per turn", v.NaturalChange)
+		}
+		label += clampLabel(v.Min, v.Max)
+		attributes := []string{fmt.Sprintf("label=%q", label)}
+		if v.Kind == GraphPolicyNode {
+			attributes = append(attributes, "shape=box")
+		} else {
+			attributes = append(attributes, "shape=ellipse")
+		}
+		if v.Unaffected {
+			attributes = append(attributes, "style=dashed")
+		}
+		lines = append(lines, fmt.Sprintf("\t%q [%s];", v.ID, strings.Join(attributes, ", ")))
+	}
+	for _, v := range g.Edges {
+		label := fmt.Sprintf("%+g", v.Weight)
+		switch v.Kind {
+		case GraphWeightChangeEdge:
+			label = fmt.Sprintf("weight of %s %+g", v.Source, v.Weight)
+		case GraphRestrictionEdge:
+			label = fmt.Sprintf("requires >= %g", v.Weight)
+		}
+		label += clampLabel(v.Min, v.Max)
+		attributes := []string{fmt.Sprintf("label=%q", label)}
+		switch v.Kind {
+		case GraphFlatEdge, GraphWeightChangeEdge:
+			attributes = append(attributes, "color=blue")
+		case GraphRestrictionEdge:
+			attributes = append(attributes, "style=dotted")
+		}
+		if v.Loop {
+			attributes = append(attributes, "color=red", "penwidth=2")
+		}
+		lines = append(lines, fmt.Sprintf("\t%q -> %q [%s];", v.From, v.To, strings.Join(attributes, ", ")))
+	}
+	lines = append(lines, "}")
+	_, err := io.WriteString(w, strings.Join(lines, "\n")+"\n")
+	return err
+}
+
+func clampLabel(min *float64, max *float64) string {
+	if nil == min && nil == max {
+		return ""
+	}
+	minLabel := "-inf"
+	if nil != min {
+		minLabel = fmt.Sprintf("%g", *min)
+	}
+	maxLabel := "inf"
+	if nil != max {
+		maxLabel = fmt.Sprintf("%g", *max)
+	}
+	return fmt.Sprintf("\n[%s, %s]", minLabel, maxLabel)
+}
@@ -0,0 +1,113 @@
+package game
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// testGraphData has a loop between a and b, c only lowers itself, d is
+// affected by nothing and f only by a policy.
+func testGraphData() *Data {
+	return LoadData([]DataRoot{{Name: "graph", FS: fstest.MapFS{
+		ValuesFile: {Data: []byte(`{"values": {
+			"a": {"affected_by": [{"name": "b", "weight": 0.5}], "min": {"set": true, "value": 0}},
+			"b": {"affected_by": [{"name": "a", "weight": -0.2, "max": {"set": true, "value": 3}}]},
+			"c": {"affected_by": [{"name": "c", "weight": -0.1}], "natural_change": -1},
+			"d": {},
+			"e": {"affected_by": [{"name": "d", "weight": 1}]},
+			"f": {}
+		}}`)},
+		PoliciesFile: {Data: []byte(`{"policies": {"p": {
+			"flat": [{"value_name": "f", "amount": 1}],
+			"weight_change": [{"dest": "e", "source": "d", "weight": 0.5}],
+			"restrictions": [{"value_name": "d", "amount": 2}]
+		}}}`)},
+		ScenarioFile: {Data: []byte(`{}`)},
+	}}}, "")
+}
+
+func TestBuildGraph(t *testing.T) {
+	graph := BuildGraph(testGraphData())
+	if !reflect.DeepEqual(graph.FeedbackLoops, [][]string{{"a", "b"}, {"c"}}) {
+		t.Errorf("got feedback loops %v, want a with b and c", graph.FeedbackLoops)
+	}
+	if !reflect.DeepEqual(graph.Unaffected, []string{"c", "d"}) {
+		t.Errorf("got unaffected %v, want c and d", graph.Unaffected)
+	}
+
+	edges := map[string]GraphEdge{}
+	for _, v := range graph.Edges {
+		edges[v.From+" "+v.Kind+" "+v.To] = v
+	}
+	if len(edges) != len(graph.Edges) || len(edges) != 7 {
+		t.Fatalf("got edges %v, want 7 different edges", graph.Edges)
+	}
+	tests := []struct {
+		key string
+		weight float64
+		loop bool
+	}{
+		{"value:b affects value:a", 0.5, true},
+		{"value:a affects value:b", -0.2, true},
+		{"value:c affects value:c", -0.1, true},
+		{"value:d affects value:e", 1, false},
+		{"policy:p flat value:f", 1, false},
+		{"policy:p weight_change value:e", 0.5, false},
+		{"value:d restriction policy:p", 2, false},
+	}
+	for _, test := range tests {
+		edge, ok := edges[test.key]
+		if !ok {
+			t.Errorf("edge %s is missing", test.key)
+			continue
+		}
+		if edge.Weight != test.weight || edge.Loop != test.loop {
+			t.Errorf("edge %s has weight %v and loop %t, want %v and %t", test.key, edge.Weight, edge.Loop, test.weight, test.loop)
+		}
+	}
+	if edge := edges["value:a affects value:b"]; nil != edge.Min || nil == edge.Max || *edge.Max != 3 {
+		t.Error("the clamp of the influence of a on b is missing")
+	}
+	if edges["policy:p weight_change value:e"].Source != "d" {
+		t.Error("the source of the weight change is missing")
+	}
+	for _, v := range graph.Nodes {
+		if v.ID == ValueNodeID("a") && (nil == v.Min || *v.Min != 0 || nil != v.Max) {
+			t.Error("the clamp of a is missing")
+		}
+	}
+}
+
+func TestWriteGraph(t *testing.T) {
+	graph := BuildGraph(testGraphData())
+	buffer := bytes.Buffer{}
+	must(t, graph.WriteJSON(&buffer))
+	read := Graph{}
+	must(t, json.Unmarshal(buffer.Bytes(), &read))
+	if !reflect.DeepEqual(&read, graph) {
+		t.Error("the JSON graph doesn't read back to the graph")
+	}
+
+	buffer.Reset()
+	must(t, graph.WriteDOT(&buffer))
+	dot := buffer.String()
+	for _, v := range []string{
+		"digraph timeline {",
+		"// feedback loop: a -> b",
+		"// feedback loop: c",
+		"// unaffected value: d",
+		`"value:d" [label="d", shape=ellipse, style=dashed];`,
+		`"value:c" [label="c\n-1 per turn", shape=ellipse, style=dashed];`,
+		`"value:a" -> "value:b" [label="-0.2\n[-inf, 3]", color=red, penwidth=2];`,
+		`"policy:p" -> "value:e" [label="weight of d +0.5", color=blue];`,
+		`"value:d" -> "policy:p" [label="requires >= 2", style=dotted];`,
+	} {
+		if !strings.Contains(dot, v) {
+			t.Errorf("the DOT graph is missing %s", v)
+		}
+	}
+}
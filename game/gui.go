@@ -45,6 +45,18 @@ func ttfFromBytesMust(b []byte, size float64) font.Face {
 	})
 }
 
+var extraGlyphs []rune
+
+// SetGlyphs adds runes to every text atlas created afterwards, needed for
+// localized strings outside of ascii and latin.
+func SetGlyphs(glyphs []rune) {
+	extraGlyphs = glyphs
+}
+
+func newAtlas(face font.Face) *text.Atlas {
+	return text.NewAtlas(face, text.ASCII, text.RangeTable(unicode.Latin), extraGlyphs)
+}
+
 type GuiElement struct {
 	
 }
@@ -60,6 +72,8 @@ func (f GuiStringProviderFunc) Provide() string {
 }
 
 type GuiPolicy struct {
+	Game *Instance
+	ID string
 	Name string
 	NormalText *text.Text
 	SelectedText *text.Text
@@ -70,14 +84,13 @@ type GuiPolicy struct {
 	OnMouseClick GuiEventHandler
 }
 
-func NewGuiPolicy(name string) *GuiPolicy {
+func NewGuiPolicy(g *Instance, id string, name string) *GuiPolicy {
 	policy := GuiPolicy{
+		Game: g,
+		ID: id,
 		Name: name,
 	}
-	regular := text.NewAtlas(
-		ttfFromBytesMust(goregular.TTF, 20),
-		text.ASCII, text.RangeTable(unicode.Latin),
-	)
+	regular := newAtlas(ttfFromBytesMust(goregular.TTF, 20))
 	policy.NormalText = text.New(pixel.ZV, regular)
 	policy.NormalText.Color = pixel.ToRGBA(colornames.Black)
 	_, err := policy.NormalText.WriteString(name)
@@ -103,9 +116,9 @@ func (p *GuiPolicy) Draw(t pixel.Target, v pixel.Vec) {
 		p.NormalText.Dot = pixel.V(0, 0)
 		p.NormalText.WriteString(p.Name)
 	}
-	store := Current.GetRewindedBranchStore()
+	store := p.Game.GetRewindedBranchStore()
 	m := pixel.IM.Moved(v)
-	_, ok := store.ActivePolicies[p.Name]
+	_, ok := store.ActivePolicies[p.ID]
 	if ok {
 		p.SelectedText.Draw(t, m)	
 		return
@@ -175,33 +188,42 @@ type GuiMenu struct {
 	Items []*GuiMenuItem
 	Position pixel.Vec
 	Bound float64
+	// Vertical stacks the items downwards instead of placing them next to
+	// each other.
+	Vertical bool
 }
 
 func (m *GuiMenu) AddItem(mi *GuiMenuItem) {
 	m.Items = append(m.Items, mi)
 }
 
+// next returns the offset of the item after the item at the offset.
+func (m *GuiMenu) next(offset pixel.Vec, item *GuiMenuItem) pixel.Vec {
+	if m.Vertical {
+		return offset.Sub(pixel.V(0, item.Text.Bounds().H()+m.Bound))
+	}
+	return offset.Add(pixel.V(item.Text.Bounds().W()+m.Bound, 0))
+}
+
 func (m *GuiMenu) Draw(t pixel.Target, relPos pixel.Vec) {
-	x := 0.0
+	offset := pixel.ZV
 	for _, v := range m.Items {
-		addedVector := pixel.Vec{X: x, Y: 0}
-		position := m.Position.Add(addedVector)
+		position := m.Position.Add(offset)
 		position = position.Add(relPos)
 		v.Draw(t, position)
-		x += v.Text.Bounds().W() + m.Bound
+		offset = m.next(offset, v)
 	}
 }
 
 func (m *GuiMenu) CheckMouse(key string, MousePosition pixel.Vec) bool {
-	x := 0.0
+	offset := pixel.ZV
 	for _, v := range m.Items {
-		addedVector := pixel.Vec{X: x, Y: 0}
 		position := MousePosition.Sub(m.Position)
-		position = position.Sub(addedVector)
+		position = position.Sub(offset)
 		if v.CheckMouse(key, position) {
 			return true
 		}
-		x += v.Text.Bounds().W() + m.Bound
+		offset = m.next(offset, v)
 	}
 	return false
 }
@@ -221,10 +243,7 @@ func NewGuiMenuItem(label string) *GuiMenuItem {
 	item := GuiMenuItem{
 		Label: label,
 	}
-	regular := text.NewAtlas(
-		ttfFromBytesMust(goregular.TTF, 30),
-		text.ASCII, text.RangeTable(unicode.Latin),
-	)
+	regular := newAtlas(ttfFromBytesMust(goregular.TTF, 30))
 	item.Text = text.New(pixel.ZV, regular)
 	item.Text.Color = pixel.ToRGBA(colornames.Black)
 	_, err := item.Text.WriteString(label)
@@ -293,30 +312,34 @@ func (s *GuiScreen) CheckMouse(key string, mousePosition pixel.Vec) bool {
 
 
 type GuiTimeLine struct {
+	Game *Instance
 	Position pixel.Vec
 	Text *text.Text
 	SideLabels []*text.Text
+	sideLabelText []string
 	MaxTime uint64
+	// ShowArchived draws the archived branches as well, deleted branches
+	// are never drawn.
+	ShowArchived bool
 	OnMouseClick GuiEventHandler	
 }
 
-func NewGuiTimeline(position pixel.Vec) *GuiTimeLine{
+func NewGuiTimeline(g *Instance, position pixel.Vec) *GuiTimeLine{
 	timeline := GuiTimeLine{
+		Game: g,
 		Position: position,
 	}
-	regular := text.NewAtlas(
-		ttfFromBytesMust(gomono.TTF, 16),
-		text.ASCII, text.RangeTable(unicode.Latin),
-	)
+	regular := newAtlas(ttfFromBytesMust(gomono.TTF, 16))
 	timeline.Text = text.New(pixel.ZV, regular)
 	timeline.Text.Color = pixel.ToRGBA(colornames.Black)
 
 	return &timeline
 }
 
-func (g *GuiTimeLine) Draw(tar pixel.Target, vec pixel.Vec) {
-	timelineStore := Current.GetTimeLineStore()
-	maxTime := uint64(0)
+// BranchLabels returns the path label of every branch, R for the root and
+// a number for each sub branch followed by the name the player gave it, in
+// the order of the timeline's branches.
+func BranchLabels(timelineStore *event.TimelineStore) []string {
 	sliceLength := len(timelineStore.Branches)
 	numberOfSubBranches := make([]int, sliceLength, sliceLength)
 	numberBranch := make([]int, sliceLength, sliceLength)
@@ -348,14 +371,68 @@ func (g *GuiTimeLine) Draw(tar pixel.Target, vec pixel.Vec) {
 			return recFunc(branch.PrevBranch)+fmt.Sprintf(".%d", subBranchCount)
 		}
 		label := recFunc(v.BranchID)
+		if gameStore, ok := timelineStore.Attributes.(*GameStore); ok {
+			if name := gameStore.BranchNames[v.BranchID]; name != "" {
+				label += " "+name
+			}
+		}
 		branchLabels = append(branchLabels, label)
 	}
 
-	for i := len(g.SideLabels); i < len(branchLabels); i++ {
-		regular := text.NewAtlas(
-			ttfFromBytesMust(gomono.TTF, 16),
-			text.ASCII, text.RangeTable(unicode.Latin),
-		)
+	return branchLabels
+}
+
+// BranchLabel returns the label of the branch.
+func BranchLabel(timelineStore *event.TimelineStore, branchID event.ID) string {
+	index, ok := timelineStore.BranchDictionary[branchID]
+	if !ok {
+		return ""
+	}
+	return BranchLabels(timelineStore)[index]
+}
+
+// visibleBranches returns the index of every branch drawn on the timeline
+// in the order they are drawn.
+func (g *GuiTimeLine) visibleBranches() []int {
+	timelineStore := g.Game.GetTimeLineStore()
+	gameStore := g.Game.GetGameStore()
+	visible := []int{}
+	for k, v := range timelineStore.Branches {
+		if _, ok := gameStore.DeletedBranches[v.BranchID]; ok {
+			continue
+		}
+		if _, ok := gameStore.ArchivedBranches[v.BranchID]; ok && !g.ShowArchived {
+			continue
+		}
+		visible = append(visible, k)
+	}
+	return visible
+}
+
+func (g *GuiTimeLine) Draw(tar pixel.Target, vec pixel.Vec) {
+	timelineStore := g.Game.GetTimeLineStore()
+	maxTime := uint64(0)
+	visible := g.visibleBranches()
+	allLabels := BranchLabels(timelineStore)
+	branchLabels := []string{}
+	for _, v := range visible {
+		branchLabels = append(branchLabels, allLabels[v])
+	}
+
+	for i := range branchLabels {
+		if i < len(g.SideLabels) {
+			if g.sideLabelText[i] == branchLabels[i] {
+				continue
+			}
+			// The branch was renamed
+			g.SideLabels[i].Clear()
+			if _, err := g.SideLabels[i].WriteString(branchLabels[i]); nil != err {
+				panic(err)
+			}
+			g.sideLabelText[i] = branchLabels[i]
+			continue
+		}
+		regular := newAtlas(ttfFromBytesMust(gomono.TTF, 16))
 		labelText := text.New(pixel.ZV, regular)
 		labelText.Color = pixel.ToRGBA(colornames.Black)
 		_, err := labelText.WriteString(branchLabels[i])
@@ -363,6 +440,7 @@ func (g *GuiTimeLine) Draw(tar pixel.Target, vec pixel.Vec) {
 			panic(err)
 		}
 		g.SideLabels = append(g.SideLabels, labelText)
+		g.sideLabelText = append(g.sideLabelText, branchLabels[i])
 	}
 
 	for _, v := range timelineStore.Branches {
@@ -372,7 +450,7 @@ func (g *GuiTimeLine) Draw(tar pixel.Target, vec pixel.Vec) {
 	}
 
 	labelY := -20.0
-	for _, v := range g.SideLabels {
+	for _, v := range g.SideLabels[:len(branchLabels)] {
 		pos := pixel.V(vec.X, labelY)
 		pos = g.Position.Add(pos)
 		pos = pos.Add(pixel.V(-v.Bounds().W(), 0))
@@ -392,8 +470,11 @@ func (g *GuiTimeLine) Draw(tar pixel.Target, vec pixel.Vec) {
 	m := pixel.IM.Moved(pos)
 	g.Text.Draw(tar, m)
 
+	gameStore := g.Game.GetGameStore()
 	imd := imdraw.New(nil)
-	for k, v := range timelineStore.Branches {
+	for k, index := range visible {
+		v := timelineStore.Branches[index]
+		_, archived := gameStore.ArchivedBranches[v.BranchID]
 		starttime := uint64(0)
 		if v.PrevBranchLastEvent != event.ZeroID() {
 			starttime = v.CreationTime
@@ -403,7 +484,17 @@ func (g *GuiTimeLine) Draw(tar pixel.Target, vec pixel.Vec) {
 		branchStore := GetBranchStore(&timelineStore.Stores[v.StoreID])
 		for i := starttime; i <= branchStore.Turn; i++ {
 			color := pixel.RGB(0,0,0)
-			if v.BranchID == Current.GetGameStore().CurrentBranch && Current.GetRewindedBranchStore().Turn == i {
+			if archived {
+				color = pixel.RGB(0.6,0.6,0.6)
+			}
+			node := Node{v.BranchID, i}
+			if _, ok := gameStore.TurnNotes[node]; ok {
+				color = pixel.RGB(1,0.5,0)
+			}
+			if gameStore.Bookmarked(node) {
+				color = pixel.RGB(0,0,1)
+			}
+			if v.BranchID == gameStore.CurrentBranch && g.Game.GetRewindedBranchStore().Turn == i {
 				color = pixel.RGB(0,1,0)
 			}
 			x := 38.45 * float64(i)
@@ -422,14 +513,15 @@ type TimelineClicked struct {
 }
 
 func (g *GuiTimeLine) CheckMouse(key string, mousePosition pixel.Vec) bool {
-	timelineStore := Current.GetTimeLineStore()
+	timelineStore := g.Game.GetTimeLineStore()
 	maxTime := uint64(0)
 	for _, v := range timelineStore.Branches {
 		if v.LastEventTime > maxTime {
 			maxTime = v.LastEventTime
 		}		
 	}
-	for k, v := range timelineStore.Branches {
+	for k, index := range g.visibleBranches() {
+		v := timelineStore.Branches[index]
 		starttime := uint64(0)
 		if v.PrevBranchLastEvent != event.ZeroID() {
 			starttime = v.CreationTime
@@ -481,6 +573,8 @@ type SaveGameList struct {
 	FileLabels []*text.Text
 	FileNames []string
 	Exists []bool
+	InfoAtlas *text.Atlas
+	Infos []*text.Text
 	OnMouseClick GuiEventHandler
 	Position pixel.Vec
 }
@@ -490,16 +584,14 @@ type SaveGameClicked struct {
 	Filename string
 }
 
-func NewSaveGameList(vec pixel.Vec) *SaveGameList {
+func NewSaveGameList(vec pixel.Vec, overwriteLabel string) *SaveGameList {
 	list := SaveGameList{}
 	list.Position = vec
-	list.Atlas = text.NewAtlas(
-		ttfFromBytesMust(goregular.TTF, 42),
-		text.ASCII, text.RangeTable(unicode.Latin),
-	)
+	list.Atlas = newAtlas(ttfFromBytesMust(goregular.TTF, 42))
+	list.InfoAtlas = newAtlas(ttfFromBytesMust(goregular.TTF, 16))
 	list.OverwriteText = text.New(pixel.ZV, list.Atlas)
 	list.OverwriteText.Color = pixel.ToRGBA(colornames.Black)
-	_, err := list.OverwriteText.WriteString("Overwrite")
+	_, err := list.OverwriteText.WriteString(overwriteLabel)
 	if err != nil {
 		panic(err)
 	}
@@ -516,6 +608,34 @@ func (list *SaveGameList) AddFile(filename string, exists bool) {
 	}
 	list.FileLabels = append(list.FileLabels, fileText)
 	list.Exists = append(list.Exists, exists)
+	list.Infos = append(list.Infos, nil)
+}
+
+// SetInfo shows the info in small print next to the file.
+func (list *SaveGameList) SetInfo(filename string, info string) {
+	for k, v := range list.FileNames {
+		if v != filename {
+			continue
+		}
+		infoText := text.New(pixel.ZV, list.InfoAtlas)
+		infoText.Color = pixel.ToRGBA(colornames.Black)
+		if _, err := infoText.WriteString(info); nil != err {
+			panic(err)
+		}
+		list.Infos[k] = infoText
+	}
+}
+
+// MarkFile adds the mark to the label of the file.
+func (list *SaveGameList) MarkFile(filename string, mark string) {
+	for k, v := range list.FileNames {
+		if v != filename {
+			continue
+		}
+		if _, err := list.FileLabels[k].WriteString(" ("+mark+")"); nil != err {
+			panic(err)
+		}
+	}
 }
 
 func (g *SaveGameList) Draw(tar pixel.Target, vec pixel.Vec) {
@@ -536,6 +656,11 @@ func (g *SaveGameList) Draw(tar pixel.Target, vec pixel.Vec) {
 		position = position.Add(pixel.V(width+20,0))
 		m = pixel.IM.Moved(position)
 		g.OverwriteText.Draw(tar, m)
+		if nil != g.Infos[k] {
+			position = position.Add(pixel.V(g.OverwriteText.Bounds().W()+20,0))
+			m = pixel.IM.Moved(position)
+			g.Infos[k].Draw(tar, m)
+		}
 		y -= bounds.H()+5
 	}
 }
@@ -579,10 +704,7 @@ func NewGuiBigText(label string, position pixel.Vec) *GuiBigText {
 	bigText := GuiBigText{}
 	bigText.Position = position
 	
-	atlas := text.NewAtlas(
-		ttfFromBytesMust(goregular.TTF, 70),
-		text.ASCII, text.RangeTable(unicode.Latin),
-	)
+	atlas := newAtlas(ttfFromBytesMust(goregular.TTF, 70))
 	bigText.Label = text.New(pixel.ZV, atlas)
 	bigText.Label.Color = pixel.ToRGBA(colornames.Black)
 	_, err :=bigText.Label.WriteString(label)
@@ -596,4 +718,28 @@ func (t *GuiBigText) Draw(tar pixel.Target, vec pixel.Vec) {
 	position := t.Position.Add(vec)
 	m := pixel.IM.Moved(position)
 	t.Label.Draw(tar, m)	
-}
\ No newline at end of file
+}
+type GuiLabel struct {
+	Label *text.Text
+	Position pixel.Vec
+	StringProvider GuiStringProvider
+}
+
+func NewGuiLabel(position pixel.Vec, provider GuiStringProvider) *GuiLabel {
+	label := GuiLabel{
+		Position: position,
+		StringProvider: provider,
+	}
+	label.Label = text.New(pixel.ZV, newAtlas(ttfFromBytesMust(goregular.TTF, 18)))
+	label.Label.Color = pixel.ToRGBA(colornames.Black)
+	return &label
+}
+
+func (l *GuiLabel) Draw(tar pixel.Target, vec pixel.Vec) {
+	l.Label.Clear()
+	l.Label.Dot = pixel.V(0, 0)
+	l.Label.WriteString(l.StringProvider.Provide())
+	position := l.Position.Add(vec)
+	m := pixel.IM.Moved(position)
+	l.Label.Draw(tar, m)
+}
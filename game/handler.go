@@ -22,42 +22,36 @@ func (g *Instance) SetPolicyHandler(e event.Event, s *event.Store) {
 		return
 	}
 
-	for _, v := range Current.GameData.Policies.MutualExclusive {
-		present := false
-		for _, v2 :=  range v {
-			if v2 == event.Policy {
-				present = true
-				break
-			}
-		}
-
-		if !present {
-			continue
-		}
-
-		for _, v2 :=  range v {
-			if _, ok := store.ActivePolicies[v2]; ok {
-				delete(store.ActivePolicies, v2)
-			}
-		}
-	}
-	
-	store.ActivePolicies[event.Policy] = struct{}{}
+	ActivatePolicy(store.ActivePolicies, g.GameData.Policies, event.Policy)
 }
 
 func (g *Instance) NextTurnHandler(e event.Event, s*event.Store) {
 	store := GetBranchStore(s)
 
 	store.ActivePolicies = ReEvaluatePolicies(store.ActivePolicies, g.GameData.Policies, store.Values)
+	store.History = append(store.History, store.turnState())
 	store.Weights = CalculateWeightMap(g.GameData.Policies, g.GameData.Values, store.ActivePolicies)
 	store.Values = RecountValues(store.Values, g.GameData.Values, store.Weights, g.GameData.Policies, store.ActivePolicies)
 	store.Turn++
+	ApplyPolicyLocks(store.ActivePolicies, g.GameData.Policies, &g.GameData.Scenario, store.Turn)
 	if store.GameOver == 0 {
 		store.GameOver = EvaluateGameEndConditions(store.Turn, &g.GameData.Scenario, store.Values)
 	}
 	fmt.Println(fmt.Sprintf("Beginning turn %d", store.Turn))
 }
 
+func (g *Instance) StoryBeatHandler(e event.Event, s *event.Store) {
+	event, ok := e.(*StoryBeatEvent)
+	if !ok {
+		panic(EventCastFailError(StoryBeatEventType, e.Type().String()))
+	}
+	store := GetBranchStore(s)
+	if store.HasStoryBeat(event.Beat) {
+		return
+	}
+	store.StoryBeats = append(store.StoryBeats, event.Beat)
+}
+
 func (g *Instance) SetBranchHandler(e event.Event, s *event.Store) {
 	event, ok := e.(*SetBranchEvent)
 	if !ok {
@@ -85,6 +79,166 @@ func (g *Instance) SetSelectedValueHandler(e event.Event, s *event.Store) {
 	store.SelectedValue = event.Value
 }
 
+func (g *Instance) SetModsHandler(e event.Event, s *event.Store) {
+	event, ok := e.(*SetModsEvent)
+	if !ok {
+		panic(EventCastFailError(SetModsEventType, e.Type().String()))
+	}
+	store := GetGameStore(s)
+	store.Mods = event.Mods
+}
+
+func (g *Instance) SetCompareBranchHandler(e event.Event, s *event.Store) {
+	event, ok := e.(*SetCompareBranchEvent)
+	if !ok {
+		panic(EventCastFailError(SetCompareBranchEventType, e.Type().String()))
+	}
+	store := GetGameStore(s)
+	store.CompareBranch = event.BranchID
+}
+
+func (g *Instance) SetBranchNameHandler(e event.Event, s *event.Store) {
+	event, ok := e.(*SetBranchNameEvent)
+	if !ok {
+		panic(EventCastFailError(SetBranchNameEventType, e.Type().String()))
+	}
+	store := GetGameStore(s)
+	if event.Name == "" {
+		delete(store.BranchNames, event.BranchID)
+		return
+	}
+	store.BranchNames[event.BranchID] = event.Name
+}
+
+func (g *Instance) SetBranchNoteHandler(e event.Event, s *event.Store) {
+	event, ok := e.(*SetBranchNoteEvent)
+	if !ok {
+		panic(EventCastFailError(SetBranchNoteEventType, e.Type().String()))
+	}
+	store := GetGameStore(s)
+	if event.Note == "" {
+		delete(store.BranchNotes, event.BranchID)
+		return
+	}
+	store.BranchNotes[event.BranchID] = event.Note
+}
+
+func (g *Instance) SetTurnNoteHandler(e event.Event, s *event.Store) {
+	event, ok := e.(*SetTurnNoteEvent)
+	if !ok {
+		panic(EventCastFailError(SetTurnNoteEventType, e.Type().String()))
+	}
+	store := GetGameStore(s)
+	if event.Note == "" {
+		delete(store.TurnNotes, event.Node)
+		return
+	}
+	store.TurnNotes[event.Node] = event.Note
+}
+
+func (g *Instance) SetBookmarkHandler(e event.Event, s *event.Store) {
+	event, ok := e.(*SetBookmarkEvent)
+	if !ok {
+		panic(EventCastFailError(SetBookmarkEventType, e.Type().String()))
+	}
+	store := GetGameStore(s)
+	bookmarks := []Node{}
+	for _, v := range store.Bookmarks {
+		if v != event.Node {
+			bookmarks = append(bookmarks, v)
+		}
+	}
+	if event.State {
+		bookmarks = append(bookmarks, event.Node)
+	}
+	store.Bookmarks = bookmarks
+}
+
+func (g *Instance) ArchiveBranchHandler(e event.Event, s *event.Store) {
+	event, ok := e.(*ArchiveBranchEvent)
+	if !ok {
+		panic(EventCastFailError(ArchiveBranchEventType, e.Type().String()))
+	}
+	store := GetGameStore(s)
+	if event.State {
+		store.ArchivedBranches[event.BranchID] = struct{}{}
+		return
+	}
+	delete(store.ArchivedBranches, event.BranchID)
+}
+
+func (g *Instance) DeleteBranchHandler(e event.Event, s *event.Store) {
+	e2, ok := e.(*DeleteBranchEvent)
+	if !ok {
+		panic(EventCastFailError(DeleteBranchEventType, e.Type().String()))
+	}
+	timeStore, ok := s.Attributes.(*event.TimelineStore)
+	if !ok {
+		panic("Store is not of type TimelineStore")
+	}
+	store := GetGameStore(s)
+	for _, v := range BranchDescendants(timeStore, e2.BranchID) {
+		store.DeletedBranches[v] = struct{}{}
+		delete(store.ArchivedBranches, v)
+		delete(store.BranchNames, v)
+		delete(store.BranchNotes, v)
+		if store.CompareBranch == v {
+			store.CompareBranch = event.ZeroID()
+		}
+	}
+	bookmarks := []Node{}
+	for _, v := range store.Bookmarks {
+		if _, ok := store.DeletedBranches[v.Branch]; !ok {
+			bookmarks = append(bookmarks, v)
+		}
+	}
+	store.Bookmarks = bookmarks
+	for k := range store.TurnNotes {
+		if _, ok := store.DeletedBranches[k.Branch]; ok {
+			delete(store.TurnNotes, k)
+		}
+	}
+}
+
+func (g *Instance) TemporalEnergyHandler(e event.Event, s *event.Store) {
+	event, ok := e.(*TemporalEnergyEvent)
+	if !ok {
+		panic(EventCastFailError(TemporalEnergyEventType, e.Type().String()))
+	}
+	store := GetGameStore(s)
+	store.TemporalEnergy += event.Amount
+	if store.TemporalEnergy < 0 {
+		store.TemporalEnergy = 0
+	}
+	if energy := g.GameData.Scenario.TemporalEnergy; nil != energy && energy.Max > 0 && store.TemporalEnergy > energy.Max {
+		store.TemporalEnergy = energy.Max
+	}
+}
+
+func (g *Instance) DiscoveryHandler(e event.Event, s *event.Store) {
+	event, ok := e.(*DiscoveryEvent)
+	if !ok {
+		panic(EventCastFailError(DiscoveryEventType, e.Type().String()))
+	}
+	store := GetGameStore(s)
+	if store.Discovered(event.Discovery) {
+		return
+	}
+	store.Discoveries = append(store.Discoveries, event.Discovery)
+}
+
+func (g *Instance) TimelineOutcomeHandler(e event.Event, s *event.Store) {
+	event, ok := e.(*TimelineOutcomeEvent)
+	if !ok {
+		panic(EventCastFailError(TimelineOutcomeEventType, e.Type().String()))
+	}
+	store := GetGameStore(s)
+	if store.Timeline != 0 {
+		return
+	}
+	store.Timeline = event.Outcome
+}
+
 func (g *Instance) WindbackHandler(e event.Event, s *event.Store) {
 	g.Dispatcher.WindbackHandler(e, s)
 	timeStore, ok := s.Attributes.(*event.TimelineStore)
@@ -0,0 +1,64 @@
+package game
+
+import (
+	"github.com/pkartner/event"
+)
+
+// SeriesPoint is the value at the start of a turn.
+type SeriesPoint struct {
+	Turn uint64
+	Value float64
+}
+
+// History returns the state of every turn of the branch, including the
+// turns it inherited from the branch it split off from. The states are
+// copies, changing them doesn't change the branch.
+func (g *Instance) History(branchID event.ID) ([]TurnState, error) {
+	store, err := g.branchStore(branchID)
+	if nil != err {
+		return nil, err
+	}
+	states := store.States()
+	for k := range states {
+		states[k] = states[k].copy()
+	}
+	return states, nil
+}
+
+// StateAt returns the state of the branch at the start of the turn.
+func (g *Instance) StateAt(branchID event.ID, turn uint64) (*TurnState, error) {
+	states, err := g.History(branchID)
+	if nil != err {
+		return nil, err
+	}
+	state := stateAt(states, turn)
+	if nil == state {
+		return nil, &UnknownTurnError{Node{branchID, turn}}
+	}
+	return state, nil
+}
+
+// ValuesAt returns the values of the branch at the start of the turn.
+func (g *Instance) ValuesAt(branchID event.ID, turn uint64) (ValueMap, error) {
+	state, err := g.StateAt(branchID, turn)
+	if nil != err {
+		return nil, err
+	}
+	return state.Values, nil
+}
+
+// Series returns the value at the start of every turn of the branch.
+func (g *Instance) Series(branchID event.ID, value string) ([]SeriesPoint, error) {
+	if _, ok := g.GameData.Values.Values[value]; !ok {
+		return nil, &UnknownValueError{value}
+	}
+	states, err := g.History(branchID)
+	if nil != err {
+		return nil, err
+	}
+	series := []SeriesPoint{}
+	for _, v := range states {
+		series = append(series, SeriesPoint{v.Turn, v.Values[value]})
+	}
+	return series, nil
+}
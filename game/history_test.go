@@ -0,0 +1,127 @@
+package game
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/pkartner/event"
+)
+
+// forkedTestGame plays the test game, the root branch ends at turn 6 with
+// collect food and the branch split off at turn 4 switched to collect
+// resources.
+func forkedTestGame(t *testing.T) (g *Instance, root event.ID, child event.ID) {
+	t.Helper()
+	g = newTestGame(t, filepath.Join(t.TempDir(), "save"), testData(t))
+	t.Cleanup(func() { g.Close() })
+	playTestGame(t, g)
+	return g, g.GetTimeLineStore().Branches[0].BranchID, g.GetGameStore().CurrentBranch
+}
+
+func TestStateAt(t *testing.T) {
+	g, root, child := forkedTestGame(t)
+	tests := []struct {
+		name string
+		branch event.ID
+		turn uint64
+		policies []string
+		err bool
+	}{
+		{"root start", root, 0, []string{"rest"}, false},
+		{"inherited turn", child, 3, []string{"collect food"}, false},
+		{"root after fork", root, 5, []string{"collect food"}, false},
+		{"child after fork", child, 5, []string{"collect resources"}, false},
+		{"child current turn", child, 6, []string{"collect resources"}, false},
+		{"turn not played", child, 7, nil, true},
+		{"unknown branch", testID(200), 0, nil, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			state, err := g.StateAt(test.branch, test.turn)
+			if test.err {
+				if nil == err {
+					t.Fatalf("got turn %d, want an error", state.Turn)
+				}
+				return
+			}
+			must(t, err)
+			if state.Turn != test.turn {
+				t.Errorf("got turn %d, want %d", state.Turn, test.turn)
+			}
+			if !reflect.DeepEqual(state.ActivePolicies, test.policies) {
+				t.Errorf("got policies %v, want %v", state.ActivePolicies, test.policies)
+			}
+		})
+	}
+}
+
+func TestValuesAt(t *testing.T) {
+	g, root, child := forkedTestGame(t)
+	tests := []struct {
+		name string
+		turn uint64
+		shared bool
+	}{
+		{"before fork", 2, true},
+		{"fork turn", 4, true},
+		{"after fork", 5, false},
+		{"current turn", 6, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rootValues, err := g.ValuesAt(root, test.turn)
+			must(t, err)
+			childValues, err := g.ValuesAt(child, test.turn)
+			must(t, err)
+			if reflect.DeepEqual(rootValues, childValues) != test.shared {
+				t.Errorf("root %v and child %v, want shared %t", rootValues, childValues, test.shared)
+			}
+
+			food := childValues["food"]
+			childValues["food"] = -100
+			again, err := g.ValuesAt(child, test.turn)
+			must(t, err)
+			if again["food"] != food {
+				t.Errorf("changing the values changed the branch, food is %v", again["food"])
+			}
+		})
+	}
+}
+
+func TestSeries(t *testing.T) {
+	g, root, child := forkedTestGame(t)
+	tests := []struct {
+		name string
+		branch event.ID
+		value string
+		err error
+	}{
+		{"root", root, "food", nil},
+		{"child", child, "food", nil},
+		{"unknown value", child, "gold", &UnknownValueError{}},
+		{"unknown branch", testID(200), "food", &UnknownBranchError{}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			series, err := g.Series(test.branch, test.value)
+			if nil != test.err {
+				if nil == err || reflect.TypeOf(err) != reflect.TypeOf(test.err) {
+					t.Fatalf("got error %v, want %T", err, test.err)
+				}
+				return
+			}
+			must(t, err)
+			if len(series) != 7 {
+				t.Fatalf("got %d points, want 7", len(series))
+			}
+			for k, v := range series {
+				values, err := g.ValuesAt(test.branch, uint64(k))
+				must(t, err)
+				if v.Turn != uint64(k) || v.Value != values[test.value] {
+					t.Errorf("point %d is %+v, want turn %d with %v", k, v, k, values[test.value])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package game
+
+import (
+	"path"
+	"sort"
+)
+
+// Locale maps text keys to translated strings. Values and policies use the
+// keys value.<id>.name, value.<id>.description, policy.<id>.name and
+// policy.<id>.description, gui strings use ui.<name>.
+type Locale map[string]string
+
+func LocaleFile(locale string) string {
+	return path.Join("locale", locale+".json")
+}
+
+// Text returns the translation for key or fallback when the locale has none.
+func (d *Data) Text(key string, fallback string) string {
+	if text, ok := d.Locale[key]; ok {
+		return text
+	}
+	return fallback
+}
+
+func (d *Data) ValueName(id string) string {
+	name := d.Values.Values[id].Name
+	if name == "" {
+		name = id
+	}
+	return d.Text("value."+id+".name", name)
+}
+
+func (d *Data) ValueDescription(id string) string {
+	return d.Text("value."+id+".description", d.Values.Values[id].Description)
+}
+
+func (d *Data) PolicyName(id string) string {
+	name := d.Policies.Policies[id].Name
+	if name == "" {
+		name = id
+	}
+	return d.Text("policy."+id+".name", name)
+}
+
+func (d *Data) PolicyDescription(id string) string {
+	return d.Text("policy."+id+".description", d.Policies.Policies[id].Description)
+}
+
+// Glyphs returns every rune used by the display strings of the data so the
+// text atlases can be built for them.
+func (d *Data) Glyphs() []rune {
+	glyphs := map[rune]struct{}{}
+	add := func(s string) {
+		for _, r := range s {
+			glyphs[r] = struct{}{}
+		}
+	}
+	for k, v := range d.Values.Values {
+		add(v.Name)
+		add(v.Description)
+		add(d.ValueName(k))
+	}
+	for k, v := range d.Policies.Policies {
+		add(v.Name)
+		add(v.Description)
+		add(d.PolicyName(k))
+	}
+	for k, v := range d.Achievements.Achievements {
+		add(v.Name)
+		add(v.Description)
+		add(d.AchievementName(k))
+	}
+	for _, v := range d.Locale {
+		add(v)
+	}
+	runes := []rune{}
+	for k := range glyphs {
+		runes = append(runes, k)
+	}
+	sort.Slice(runes, func(i, j int) bool {
+		return runes[i] < runes[j]
+	})
+	return runes
+}
@@ -0,0 +1,112 @@
+package game
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// codeTextKeys returns the keys the code looks up by a literal, in the game
+// and in the main package.
+func codeTextKeys(t *testing.T) map[string]struct{} {
+	t.Helper()
+	keys := map[string]struct{}{}
+	files := []string{}
+	for _, v := range []string{"*.go", filepath.Join("..", "*.go")} {
+		matches, err := filepath.Glob(v)
+		must(t, err)
+		files = append(files, matches...)
+	}
+	fileSet := token.NewFileSet()
+	for _, v := range files {
+		if strings.HasSuffix(v, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fileSet, v, nil, 0)
+		must(t, err)
+		ast.Inspect(file, func(node ast.Node) bool {
+			call, ok := node.(*ast.CallExpr)
+			if !ok || len(call.Args) != 2 {
+				return true
+			}
+			selector, ok := call.Fun.(*ast.SelectorExpr)
+			if !ok || selector.Sel.Name != "Text" {
+				return true
+			}
+			literal, ok := call.Args[0].(*ast.BasicLit)
+			if !ok || literal.Kind != token.STRING {
+				return true
+			}
+			key, err := strconv.Unquote(literal.Value)
+			must(t, err)
+			keys[key] = struct{}{}
+			return true
+		})
+	}
+	return keys
+}
+
+// dataTextKeys returns the keys of the texts in the data.
+func dataTextKeys(gameData *Data) map[string]struct{} {
+	keys := map[string]struct{}{}
+	add := func(key string) {
+		keys[key] = struct{}{}
+	}
+	for k := range gameData.Values.Values {
+		add("value."+k+".name")
+		add("value."+k+".description")
+	}
+	for k := range gameData.Policies.Policies {
+		add("policy."+k+".name")
+		add("policy."+k+".description")
+	}
+	for k := range gameData.Achievements.Achievements {
+		add("achievement."+k+".name")
+		add("achievement."+k+".description")
+	}
+	if nil != gameData.Scenario.Intro {
+		add("intro.title")
+		add("intro.text")
+	}
+	for _, v := range gameData.Scenario.Story {
+		add("story."+v.ID)
+	}
+	for _, v := range gameData.Scenario.Discoveries {
+		add("discovery."+v.ID)
+	}
+	return keys
+}
+
+func TestLocalesAreComplete(t *testing.T) {
+	keys := codeTextKeys(t)
+	if _, ok := keys["ui.end_turn"]; !ok {
+		t.Fatal("the keys of the code weren't found")
+	}
+	for k := range dataTextKeys(testData(t)) {
+		keys[k] = struct{}{}
+	}
+	files, err := filepath.Glob(filepath.Join("..", "data", "locale", "*.json"))
+	must(t, err)
+	if len(files) == 0 {
+		t.Fatal("no locales found")
+	}
+	for _, v := range files {
+		t.Run(filepath.Base(v), func(t *testing.T) {
+			data, err := os.ReadFile(v)
+			must(t, err)
+			locale := Locale{}
+			must(t, json.Unmarshal(data, &locale))
+			for k := range keys {
+				if _, ok := locale[k]; !ok {
+					t.Errorf("%s is missing", k)
+				}
+			}
+		})
+	}
+}
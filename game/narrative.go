@@ -0,0 +1,140 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Intro struct {
+	Title string `json:"title"`
+	Text string `json:"text"`
+}
+
+// StoryBeat is a message shown once per branch as soon as its condition
+// holds, conditions on turn trigger at a turn.
+type StoryBeat struct {
+	ID string `json:"id"`
+	Condition *GameEndCondition `json:"condition"`
+	Text string `json:"text"`
+}
+
+func (d *Data) IntroTitle() string {
+	if nil == d.Scenario.Intro {
+		return ""
+	}
+	return d.Text("intro.title", d.Scenario.Intro.Title)
+}
+
+func (d *Data) IntroText() string {
+	if nil == d.Scenario.Intro {
+		return ""
+	}
+	return d.Text("intro.text", d.Scenario.Intro.Text)
+}
+
+func (d *Data) StoryBeatText(id string) string {
+	for _, v := range d.Scenario.Story {
+		if v.ID == id {
+			return d.Text("story."+id, v.Text)
+		}
+	}
+	return ""
+}
+
+// Objectives describes the win and lose conditions of the scenario.
+func (d *Data) Objectives() []string {
+	objectives := []string{}
+	if nil != d.Scenario.WinCondition {
+		objectives = append(objectives, d.Text("ui.objective_win", "Win")+": "+d.DescribeCondition(d.Scenario.WinCondition))
+	}
+	if nil != d.Scenario.LoseCondition {
+		objectives = append(objectives, d.Text("ui.objective_lose", "Lose")+": "+d.DescribeCondition(d.Scenario.LoseCondition))
+	}
+	if nil != d.Scenario.TimelineWin {
+		objectives = append(objectives, d.Text("ui.objective_timeline_win", "Save the timeline")+": "+d.DescribeTimelineObjective(d.Scenario.TimelineWin))
+	}
+	if nil != d.Scenario.TimelineLose {
+		objectives = append(objectives, d.Text("ui.objective_timeline_lose", "Timeline collapses")+": "+d.DescribeTimelineObjective(d.Scenario.TimelineLose))
+	}
+	return objectives
+}
+
+func (d *Data) DescribeCondition(condition *GameEndCondition) string {
+	if condition.Name == "turn" {
+		return fmt.Sprintf(d.Text("ui.condition_turn", "reach turn %v"), condition.Value)
+	}
+	if condition.Sign == "-" {
+		return fmt.Sprintf(d.Text("ui.condition_below", "%s drops to %v or less"), d.ValueName(condition.Name), condition.Value)
+	}
+	return fmt.Sprintf(d.Text("ui.condition_above", "%s reaches %v or more"), d.ValueName(condition.Name), condition.Value)
+}
+
+// NextStoryBeat returns the event for the first story beat of the scenario
+// that is triggered but not yet told on the current branch, nil when there
+// is none.
+func (g *Instance) NextStoryBeat() *StoryBeatEvent {
+	store := g.GetCurrentBranchStore()
+	for _, v := range g.GameData.Scenario.Story {
+		if store.HasStoryBeat(v.ID) {
+			continue
+		}
+		if nil == v.Condition || !conditionHolds(store.Turn, v.Condition, store.Values) {
+			continue
+		}
+		return g.TellStoryBeat(v.ID)
+	}
+	return nil
+}
+
+// DispatchStoryBeats tells every story beat triggered on the current branch.
+func (g *Instance) DispatchStoryBeats() {
+	for e := g.NextStoryBeat(); nil != e; e = g.NextStoryBeat() {
+		g.Dispatch(e)
+	}
+}
+
+func (s *BranchStore) HasStoryBeat(id string) bool {
+	for _, v := range s.StoryBeats {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+func mergeStory(story []StoryBeat, beats []StoryBeat) []StoryBeat {
+	for _, v := range beats {
+		replaced := false
+		for k, v2 := range story {
+			if v2.ID == v.ID {
+				story[k] = v
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			story = append(story, v)
+		}
+	}
+	return story
+}
+
+// WrapText breaks the text into lines of at most width characters.
+func WrapText(text string, width int) string {
+	lines := []string{}
+	for _, paragraph := range strings.Split(text, "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			if line != "" && len([]rune(line))+1+len([]rune(word)) > width {
+				lines = append(lines, line)
+				line = ""
+			}
+			if line != "" {
+				line += " "
+			}
+			line += word
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
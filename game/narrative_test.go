@@ -0,0 +1,41 @@
+package game
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestStoryBeatConditions(t *testing.T) {
+	gameData := testData(t)
+	gameData.Scenario.Story = []StoryBeat{
+		{ID: "gold", Condition: &GameEndCondition{Name: "gold", Sign: "+", Value: 1}},
+		{ID: "second_turn", Condition: &GameEndCondition{Name: "turn", Sign: "+", Value: 2}},
+	}
+	g := newTestGame(t, filepath.Join(t.TempDir(), "save"), gameData)
+	defer g.Close()
+	tests := []struct {
+		turn uint64
+		told []string
+		untold []string
+	}{
+		{1, nil, []string{"gold", "second_turn"}},
+		{2, []string{"second_turn"}, []string{"gold"}},
+	}
+	for _, test := range tests {
+		must(t, g.EndTurn())
+		store := g.GetCurrentBranchStore()
+		if store.Turn != test.turn {
+			t.Fatalf("at turn %d, want %d", store.Turn, test.turn)
+		}
+		for _, v := range test.told {
+			if !store.HasStoryBeat(v) {
+				t.Errorf("%s isn't told at turn %d", v, test.turn)
+			}
+		}
+		for _, v := range test.untold {
+			if store.HasStoryBeat(v) {
+				t.Errorf("%s is told at turn %d", v, test.turn)
+			}
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkartner/event"
+)
+
+// TimelineObjective is met by the timeline as a whole. Each entry of
+// Branches is a set of conditions that has to hold on a branch of its own,
+// WonBranches is the number of won branches needed and AllLost is met once
+// every branch is lost and there isn't enough temporal energy left to go
+// back and play on from an earlier turn. An objective with several parts
+// needs all of them.
+type TimelineObjective struct {
+	Branches [][]GameEndCondition `json:"branches"`
+	WonBranches int `json:"won_branches"`
+	AllLost bool `json:"all_lost"`
+}
+
+type TimelineOverError struct {
+	Outcome uint8
+}
+
+func (e *TimelineOverError) Error() string {
+	if e.Outcome == GameWon {
+		return "The timeline is already won"
+	}
+	return "The timeline has collapsed"
+}
+
+// timelineBranches returns the present state of every branch that isn't
+// deleted.
+func timelineBranches(timeStore *event.TimelineStore, gameStore *GameStore) []*BranchStore {
+	stores := []*BranchStore{}
+	for _, v := range timeStore.Branches {
+		if _, ok := gameStore.DeletedBranches[v.BranchID]; ok {
+			continue
+		}
+		stores = append(stores, GetBranchStore(&timeStore.Stores[v.StoreID]))
+	}
+	return stores
+}
+
+// Met returns if the branches meet every part of the objective, recoverable
+// tells if the player can still play on from an earlier turn.
+func (o *TimelineObjective) Met(stores []*BranchStore, recoverable bool) bool {
+	if len(o.Branches) == 0 && o.WonBranches == 0 && !o.AllLost {
+		return false
+	}
+	if len(stores) == 0 {
+		return false
+	}
+	won := 0
+	lost := 0
+	for _, v := range stores {
+		switch v.GameOver {
+		case GameWon:
+			won++
+		case GameLost:
+			lost++
+		}
+	}
+	if won < o.WonBranches {
+		return false
+	}
+	if o.AllLost && (lost < len(stores) || recoverable) {
+		return false
+	}
+	return assignBranches(o.Branches, stores, map[int]struct{}{})
+}
+
+// assignBranches tries to give every set of conditions a branch of its own
+// on which it holds.
+func assignBranches(requirements [][]GameEndCondition, stores []*BranchStore, used map[int]struct{}) bool {
+	if len(requirements) == 0 {
+		return true
+	}
+	for k, v := range stores {
+		if _, ok := used[k]; ok {
+			continue
+		}
+		if !conditionsHold(requirements[0], v) {
+			continue
+		}
+		used[k] = struct{}{}
+		if assignBranches(requirements[1:], stores, used) {
+			return true
+		}
+		delete(used, k)
+	}
+	return false
+}
+
+func conditionsHold(conditions []GameEndCondition, store *BranchStore) bool {
+	for k := range conditions {
+		if !conditionHolds(store.Turn, &conditions[k], store.Values) {
+			return false
+		}
+	}
+	return true
+}
+
+// timelineRecoverable returns if the player can wind one of the branches
+// back a turn and branch off there with the temporal energy left, the
+// cheapest way to play on.
+func timelineRecoverable(stores []*BranchStore, gameStore *GameStore, scenario *Scenario) bool {
+	if energy := scenario.TemporalEnergy; nil != energy {
+		cost := energy.WindbackCost+energy.WindbackCostPerTurn+energy.BranchCost+energy.BranchCostPerTurn
+		if cost > gameStore.TemporalEnergy {
+			return false
+		}
+	}
+	for _, v := range stores {
+		if v.Turn > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// EvaluateTimelineObjectives returns the outcome of the timeline, the win is
+// checked before the loss.
+func EvaluateTimelineObjectives(timeStore *event.TimelineStore, scenario *Scenario) uint8 {
+	gameStore, ok := timeStore.Attributes.(*GameStore)
+	if !ok {
+		panic("Store is not of type GameStore")
+	}
+	stores := timelineBranches(timeStore, gameStore)
+	recoverable := timelineRecoverable(stores, gameStore, scenario)
+	if nil != scenario.TimelineWin && scenario.TimelineWin.Met(stores, recoverable) {
+		return GameWon
+	}
+	if nil != scenario.TimelineLose && scenario.TimelineLose.Met(stores, recoverable) {
+		return GameLost
+	}
+	return 0
+}
+
+// DispatchTimelineOutcome ends the game once the timeline objectives are won
+// or lost.
+func (g *Instance) DispatchTimelineOutcome() {
+	if g.GetGameStore().Timeline != 0 {
+		return
+	}
+	outcome := EvaluateTimelineObjectives(g.GetTimeLineStore(), &g.GameData.Scenario)
+	if outcome == 0 {
+		return
+	}
+	g.Dispatch(g.SetTimelineOutcome(outcome))
+}
+
+func (d *Data) DescribeTimelineObjective(objective *TimelineObjective) string {
+	parts := []string{}
+	for _, v := range objective.Branches {
+		conditions := []string{}
+		for k := range v {
+			conditions = append(conditions, d.DescribeCondition(&v[k]))
+		}
+		parts = append(parts, fmt.Sprintf(d.Text("ui.condition_branch", "%s on a branch of its own"), strings.Join(conditions, ", ")))
+	}
+	if objective.WonBranches > 0 {
+		parts = append(parts, fmt.Sprintf(d.Text("ui.condition_won_branches", "win on %d branches"), objective.WonBranches))
+	}
+	if objective.AllLost {
+		parts = append(parts, d.Text("ui.condition_all_lost", "every branch is lost without energy to go back"))
+	}
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,173 @@
+package game
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/pkartner/event"
+)
+
+func testBranchStore(gameOver uint8, values ValueMap) *BranchStore {
+	return &BranchStore{Turn: 5, Values: values, GameOver: gameOver}
+}
+
+func TestTimelineObjectiveMet(t *testing.T) {
+	shelter := []GameEndCondition{{Name: "shelter", Sign: "+", Value: 10}}
+	food := []GameEndCondition{{Name: "food", Sign: "+", Value: 20}}
+	both := ValueMap{"shelter": 12, "food": 25}
+	onlyShelter := ValueMap{"shelter": 12, "food": 5}
+	onlyFood := ValueMap{"shelter": 2, "food": 25}
+	tests := []struct {
+		name string
+		objective TimelineObjective
+		stores []*BranchStore
+		met bool
+	}{
+		{"empty objective", TimelineObjective{}, []*BranchStore{testBranchStore(GameWon, both)}, false},
+		{"no branches", TimelineObjective{WonBranches: 1}, []*BranchStore{}, false},
+		{"enough won branches", TimelineObjective{WonBranches: 2}, []*BranchStore{
+			testBranchStore(GameWon, both),
+			testBranchStore(GameLost, both),
+			testBranchStore(GameWon, both),
+		}, true},
+		{"too few won branches", TimelineObjective{WonBranches: 2}, []*BranchStore{
+			testBranchStore(GameWon, both),
+			testBranchStore(GameLost, both),
+			testBranchStore(0, both),
+		}, false},
+		{"all lost", TimelineObjective{AllLost: true}, []*BranchStore{
+			testBranchStore(GameLost, both),
+			testBranchStore(GameLost, both),
+		}, true},
+		{"not all lost", TimelineObjective{AllLost: true}, []*BranchStore{
+			testBranchStore(GameLost, both),
+			testBranchStore(0, both),
+		}, false},
+		{"distinct branches", TimelineObjective{Branches: [][]GameEndCondition{shelter, food}}, []*BranchStore{
+			testBranchStore(0, onlyShelter),
+			testBranchStore(0, onlyFood),
+		}, true},
+		{"first part moves to a free branch", TimelineObjective{Branches: [][]GameEndCondition{shelter, food}}, []*BranchStore{
+			testBranchStore(0, both),
+			testBranchStore(0, onlyShelter),
+		}, true},
+		{"same branch twice", TimelineObjective{Branches: [][]GameEndCondition{shelter, food}}, []*BranchStore{
+			testBranchStore(0, both),
+		}, false},
+		{"branch taken by an earlier part", TimelineObjective{Branches: [][]GameEndCondition{shelter, shelter}}, []*BranchStore{
+			testBranchStore(0, onlyShelter),
+			testBranchStore(0, onlyFood),
+		}, false},
+		{"every part needed", TimelineObjective{Branches: [][]GameEndCondition{shelter}, WonBranches: 1}, []*BranchStore{
+			testBranchStore(0, onlyShelter),
+		}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if met := test.objective.Met(test.stores, false); met != test.met {
+				t.Errorf("met is %t, want %t", met, test.met)
+			}
+		})
+	}
+}
+
+func TestAllLostNeedsNoWayBack(t *testing.T) {
+	lost := []*BranchStore{testBranchStore(GameLost, ValueMap{}), testBranchStore(GameLost, ValueMap{})}
+	objective := TimelineObjective{AllLost: true}
+	if objective.Met(lost, true) {
+		t.Error("met while the player can still go back")
+	}
+	if !objective.Met(lost, false) {
+		t.Error("not met without a way back")
+	}
+
+	energy := &TemporalEnergy{WindbackCostPerTurn: 1, BranchCost: 2, BranchCostPerTurn: 0.5}
+	tests := []struct {
+		name string
+		energy *TemporalEnergy
+		left float64
+		turn uint64
+		recoverable bool
+	}{
+		{"enough energy", energy, 3.5, 5, true},
+		{"too little energy", energy, 3, 5, false},
+		{"no energy rules", nil, 0, 5, true},
+		{"no earlier turn", nil, 0, 0, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := testBranchStore(GameLost, ValueMap{})
+			store.Turn = test.turn
+			gameStore := NewGameStore()
+			gameStore.TemporalEnergy = test.left
+			scenario := &Scenario{TemporalEnergy: test.energy}
+			if got := timelineRecoverable([]*BranchStore{store}, gameStore, scenario); got != test.recoverable {
+				t.Errorf("recoverable is %t, want %t", got, test.recoverable)
+			}
+		})
+	}
+}
+
+func TestLostTimeline(t *testing.T) {
+	tests := []struct {
+		name string
+		energy float64
+		over bool
+	}{
+		{"energy to go back", 10, false},
+		{"no energy to go back", 0, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gameData := testData(t)
+			if nil == gameData.Scenario.TimelineLose || !gameData.Scenario.TimelineLose.AllLost {
+				t.Fatal("the scenario has no all lost objective")
+			}
+			energy := *gameData.Scenario.TemporalEnergy
+			energy.Start = test.energy
+			energy.Regen = nil
+			gameData.Scenario.TemporalEnergy = &energy
+			g := newTestGame(t, filepath.Join(t.TempDir(), "save"), gameData)
+			defer g.Close()
+			root := g.GetGameStore().CurrentBranch
+			for g.GetCurrentBranchStore().GameOver != GameLost {
+				must(t, g.EndTurn())
+			}
+			if over := g.GetGameStore().Timeline == GameLost; over != test.over {
+				t.Fatalf("timeline lost is %t, want %t", over, test.over)
+			}
+			if test.over {
+				return
+			}
+			must(t, g.JumpTo(Node{root, 4}))
+			must(t, g.ChangePolicy("collect food"))
+			must(t, g.EndTurn())
+			if len(g.GetTimeLineStore().Branches) != 2 {
+				t.Errorf("got %d branches, want 2", len(g.GetTimeLineStore().Branches))
+			}
+		})
+	}
+}
+
+func TestTimelineBranchesSkipsDeleted(t *testing.T) {
+	won := testBranchStore(GameWon, ValueMap{})
+	deleted := testBranchStore(GameWon, ValueMap{})
+	timeStore := &event.TimelineStore{
+		Stores: []event.Store{{Attributes: won}, {Attributes: deleted}},
+		Branches: []event.Branch{
+			{BranchID: testID(1), StoreID: 0},
+			{BranchID: testID(2), StoreID: 1},
+		},
+	}
+	gameStore := NewGameStore()
+	gameStore.DeletedBranches[testID(2)] = struct{}{}
+
+	stores := timelineBranches(timeStore, gameStore)
+	if len(stores) != 1 || stores[0] != won {
+		t.Fatalf("got %d branches, want only the branch that isn't deleted", len(stores))
+	}
+	objective := TimelineObjective{WonBranches: 2}
+	if objective.Met(stores, false) {
+		t.Error("the deleted branch counts as won")
+	}
+}
@@ -0,0 +1,65 @@
+package game
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// Profile holds what a player keeps across saves.
+type Profile struct {
+	// Achievements maps the unlocked achievements to the time they were
+	// unlocked.
+	Achievements map[string]uint64 `json:"achievements"`
+	// Retired holds the statistics of the saves that were overwritten.
+	Retired Statistics `json:"retired"`
+	fileName string
+}
+
+// LoadProfile reads the profile file, a missing file is an empty profile.
+func LoadProfile(fileName string) (*Profile, error) {
+	profile := &Profile{
+		fileName: fileName,
+	}
+	data, err := ioutil.ReadFile(fileName)
+	if nil != err && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if nil == err {
+		if err := json.Unmarshal(data, profile); nil != err {
+			return nil, err
+		}
+	}
+	if nil == profile.Achievements {
+		profile.Achievements = map[string]uint64{}
+	}
+	return profile, nil
+}
+
+func (p *Profile) Save() error {
+	data, err := json.MarshalIndent(p, "", "    ")
+	if nil != err {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p.fileName), os.ModePerm); nil != err {
+		return err
+	}
+	return ioutil.WriteFile(p.fileName, data, 0600)
+}
+
+// Unlock records the achievement, it returns false when the achievement was
+// unlocked before.
+func (p *Profile) Unlock(id string, time uint64) (bool, error) {
+	if _, ok := p.Achievements[id]; ok {
+		return false, nil
+	}
+	p.Achievements[id] = time
+	return true, p.Save()
+}
+
+// Retire keeps the statistics of a save that is about to be overwritten.
+func (p *Profile) Retire(stats *Statistics) error {
+	p.Retired.Add(stats)
+	return p.Save()
+}
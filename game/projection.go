@@ -0,0 +1,87 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkartner/event"
+)
+
+// Projection is a read model kept up to date from the events of a game, it
+// answers queries without going through the branch stores.
+type Projection interface {
+	// Name identifies the projection, it has to be unique within a game.
+	Name() string
+	// Reset clears the projection before it is rebuilt from the event log.
+	Reset()
+	// Apply is called with every event after its handler ran, the game
+	// holds the state right after the event.
+	Apply(g *Instance, e event.Event)
+	// MarshalState and UnmarshalState store the projection in snapshots.
+	MarshalState() ([]byte, error)
+	UnmarshalState(data []byte) error
+}
+
+// RestoredProjection is a projection that has to act on the state it was
+// restored to from a snapshot, the events before the snapshot aren't applied
+// again.
+type RestoredProjection interface {
+	Projection
+	Restored(g *Instance)
+}
+
+// ProjectionFactory creates fresh projections for every game instance,
+// projections hold the state of one game and can't be shared.
+type ProjectionFactory func() []Projection
+
+// Projection returns the projection with the name, nil when there is none.
+func (g *Instance) Projection(name string) Projection {
+	for _, v := range g.projections {
+		if v.Name() == name {
+			return v
+		}
+	}
+	return nil
+}
+
+func (g *Instance) applyProjections(e event.Event) {
+	for _, v := range g.projections {
+		v.Apply(g, e)
+	}
+}
+
+func (g *Instance) projectionStates() (map[string]json.RawMessage, error) {
+	states := map[string]json.RawMessage{}
+	for _, v := range g.projections {
+		state, err := v.MarshalState()
+		if nil != err {
+			return nil, fmt.Errorf("Projection %s: %s", v.Name(), err)
+		}
+		states[v.Name()] = state
+	}
+	return states, nil
+}
+
+// hasProjectionStates returns if the snapshot can restore every projection
+// of the game.
+func (g *Instance) hasProjectionStates(snapshot *Snapshot) bool {
+	for _, v := range g.projections {
+		if _, ok := snapshot.Projections[v.Name()]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (g *Instance) restoreProjections(snapshot *Snapshot) error {
+	for _, v := range g.projections {
+		v.Reset()
+		if err := v.UnmarshalState(snapshot.Projections[v.Name()]); nil != err {
+			return fmt.Errorf("Projection %s: %s", v.Name(), err)
+		}
+		if restored, ok := v.(RestoredProjection); ok {
+			restored.Restored(g)
+		}
+	}
+	return nil
+}
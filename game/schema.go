@@ -0,0 +1,122 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkartner/event"
+)
+
+// EventSchema is embedded in every game event and holds the schema version
+// of its payload. Events persisted before versioning existed have no version
+// field and are upcast from version 0. PrevHash is the hash of the event chain before the event, see
+// ChainedEventStore.
+type EventSchema struct {
+	Version uint32
+	PrevHash string
+}
+
+func (s *EventSchema) SchemaVersion() uint32 {
+	return s.Version
+}
+
+type VersionedEvent interface {
+	event.Event
+	SchemaVersion() uint32
+}
+
+// Upcaster converts the payload of an event from the version it is
+// registered for to the next version. It works on the raw JSON fields, so
+// fields that were renamed or retyped can still be read. The version field
+// is raised after the upcaster ran.
+type Upcaster func(fields map[string]json.RawMessage) error
+
+var schemaVersions = map[event.EventType]uint32{}
+var upcasters = map[event.EventType]map[uint32]Upcaster{}
+
+// CurrentSchemaVersion returns the version new events of the type are
+// created with.
+func CurrentSchemaVersion(eventType event.EventType) uint32 {
+	return schemaVersions[eventType]
+}
+
+// RegisterSchemaVersion sets the current version of the event type, every
+// older version needs an upcaster.
+func RegisterSchemaVersion(eventType event.EventType, version uint32) {
+	schemaVersions[eventType] = version
+}
+
+func RegisterUpcaster(eventType event.EventType, from uint32, upcaster Upcaster) {
+	if nil == upcasters[eventType] {
+		upcasters[eventType] = map[uint32]Upcaster{}
+	}
+	upcasters[eventType][from] = upcaster
+}
+
+// StampVersion is the upcaster for versions that only introduced the
+// version field itself.
+func StampVersion(fields map[string]json.RawMessage) error {
+	return nil
+}
+
+// Upcast runs the upcasters of the event type on the payload until it is at
+// the current version. A payload without a version field is at version 0.
+func Upcast(eventType event.EventType, payload []byte) ([]byte, error) {
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(payload, &fields); nil != err {
+		return nil, fmt.Errorf("Could not read event %s: %s", eventType, err)
+	}
+	version := uint32(0)
+	if raw, ok := fields["Version"]; ok {
+		if err := json.Unmarshal(raw, &version); nil != err {
+			return nil, fmt.Errorf("Could not read the version of event %s: %s", eventType, err)
+		}
+	}
+	current := CurrentSchemaVersion(eventType)
+	if version > current {
+		return nil, fmt.Errorf("Event %s has version %d, newer than the supported version %d", eventType, version, current)
+	}
+	if version == current {
+		return payload, nil
+	}
+	for ; version < current; version++ {
+		upcaster, ok := upcasters[eventType][version]
+		if !ok {
+			return nil, fmt.Errorf("No upcaster for event %s from version %d", eventType, version)
+		}
+		if err := upcaster(fields); nil != err {
+			return nil, err
+		}
+		raw, err := json.Marshal(version+1)
+		if nil != err {
+			return nil, err
+		}
+		fields["Version"] = raw
+	}
+	return json.Marshal(fields)
+}
+
+func init() {
+	for _, v := range []event.EventType{
+		NextTurnEventType,
+		SetPolicyEventType,
+		SetBranchEventType,
+		SetScreenEventType,
+		SetSelectedValueType,
+		SetModsEventType,
+		StoryBeatEventType,
+		SetCompareBranchEventType,
+		SetBranchNameEventType,
+		SetBranchNoteEventType,
+		SetTurnNoteEventType,
+		SetBookmarkEventType,
+		ArchiveBranchEventType,
+		DeleteBranchEventType,
+		TemporalEnergyEventType,
+		DiscoveryEventType,
+		TimelineOutcomeEventType,
+	} {
+		RegisterSchemaVersion(v, 1)
+		RegisterUpcaster(v, 0, StampVersion)
+	}
+}
@@ -0,0 +1,319 @@
+package game
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/boltdb/bolt"
+	"github.com/pkartner/event"
+)
+
+// copyTestData copies a file from testdata so tests can't change it, it
+// returns the file name of the copy without .db. The files in testdata were
+// written by older versions of the game and must not be regenerated.
+func copyTestData(t *testing.T, dir string, name string) string {
+	t.Helper()
+	source, err := os.Open(filepath.Join("testdata", dir, name+".db"))
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer source.Close()
+	fileName := filepath.Join(t.TempDir(), name)
+	target, err := os.Create(fileName+".db")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer target.Close()
+	if _, err := io.Copy(target, source); nil != err {
+		t.Fatal(err)
+	}
+	return fileName
+}
+
+// openTestEvents opens a copy of a file from testdata/events, these only hold
+// events to decode and aren't complete saves.
+func openTestEvents(t *testing.T, name string) *bolt.DB {
+	t.Helper()
+	db, err := bolt.Open(copyTestData(t, "events", name)+".db", 0600, nil)
+	if nil != err {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestOldEventsDecode(t *testing.T) {
+	tests := []struct {
+		save string
+		// modified is set for saves whose events have no hashes, the
+		// game never stored events like that
+		modified bool
+		types []event.EventType
+	}{
+		{"v0", true, []event.EventType{
+			SetBranchEventType,
+			SetModsEventType,
+			SetScreenEventType,
+			SetPolicyEventType,
+			NextTurnEventType,
+			StoryBeatEventType,
+			SetSelectedValueType,
+		}},
+		{"v1", false, []event.EventType{
+			SetBranchEventType,
+			SetPolicyEventType,
+			NextTurnEventType,
+			StoryBeatEventType,
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.save, func(t *testing.T) {
+			store := NewChainedEventStore(openTestEvents(t, test.save))
+			events := []event.Event{}
+			err := store.Restore(^uint64(0), func(e event.Event) error {
+				events = append(events, e)
+				return nil
+			})
+			if nil != err {
+				t.Fatal(err)
+			}
+			if store.Modified != test.modified {
+				t.Errorf("modified is %t, want %t", store.Modified, test.modified)
+			}
+			if len(events) != len(test.types) {
+				t.Fatalf("got %d events, want %d", len(events), len(test.types))
+			}
+			for k, v := range events {
+				if v.Type() != test.types[k] {
+					t.Errorf("event %d is %s, want %s", k, v.Type(), test.types[k])
+				}
+				versioned, ok := v.(VersionedEvent)
+				if !ok {
+					continue
+				}
+				if versioned.SchemaVersion() != CurrentSchemaVersion(v.Type()) {
+					t.Errorf("event %s has version %d, want %d", v.Type(), versioned.SchemaVersion(), CurrentSchemaVersion(v.Type()))
+				}
+				switch e := v.(type) {
+				case *SetPolicyEvent:
+					if e.Policy != "collect food" || !e.State {
+						t.Errorf("policy event decoded as %s %t", e.Policy, e.State)
+					}
+				case *StoryBeatEvent:
+					if e.Beat != "first_day" || e.BranchEventTime != 1 {
+						t.Errorf("story beat decoded as %s at %d", e.Beat, e.BranchEventTime)
+					}
+				}
+			}
+		})
+	}
+}
+
+const renamedTestEventType = "test_renamed"
+
+type renamedTestEvent struct {
+	event.BaseEvent
+	EventSchema
+	Title string
+}
+
+func (e *renamedTestEvent) Type() event.EventType {
+	return renamedTestEventType
+}
+
+// registerRenamedTestEvent registers an event whose Name field became Title
+// in version 2.
+func registerRenamedTestEvent(t *testing.T) {
+	eventPrototypes[renamedTestEventType] = func() event.Event { return &renamedTestEvent{} }
+	RegisterSchemaVersion(renamedTestEventType, 2)
+	RegisterUpcaster(renamedTestEventType, 0, StampVersion)
+	RegisterUpcaster(renamedTestEventType, 1, func(fields map[string]json.RawMessage) error {
+		fields["Title"] = fields["Name"]
+		delete(fields, "Name")
+		return nil
+	})
+	t.Cleanup(func() {
+		delete(eventPrototypes, renamedTestEventType)
+		delete(schemaVersions, renamedTestEventType)
+		delete(upcasters, renamedTestEventType)
+	})
+}
+
+func TestDecodeEventUpcastsRawPayload(t *testing.T) {
+	registerRenamedTestEvent(t)
+	tests := []struct {
+		payload string
+		title string
+		fails bool
+	}{
+		{`{"EventTime": 1, "Name": "storm"}`, "storm", false},
+		{`{"EventTime": 1, "Version": 1, "Name": "storm"}`, "storm", false},
+		{`{"EventTime": 1, "Version": 2, "Title": "storm"}`, "storm", false},
+		{`{"EventTime": 1, "Version": 3, "Title": "storm"}`, "", true},
+	}
+	for _, test := range tests {
+		e, err := DecodeEvent(renamedTestEventType, []byte(test.payload))
+		if test.fails {
+			if nil == err {
+				t.Errorf("%s: expected an error", test.payload)
+			}
+			continue
+		}
+		if nil != err {
+			t.Errorf("%s: %s", test.payload, err)
+			continue
+		}
+		renamed := e.(*renamedTestEvent)
+		if renamed.Title != test.title || renamed.Version != 2 {
+			t.Errorf("%s: decoded title %q at version %d", test.payload, renamed.Title, renamed.Version)
+		}
+	}
+}
+
+func TestDecodeEventWithoutUpcaster(t *testing.T) {
+	registerRenamedTestEvent(t)
+	delete(upcasters[renamedTestEventType], 1)
+	if _, err := DecodeEvent(renamedTestEventType, []byte(`{"Version": 1, "Name": "storm"}`)); nil == err {
+		t.Error("expected an error for a missing upcaster")
+	}
+}
+
+// legacyEventStore hands out events the way the bolt store of the event
+// library did for saves made before the events were kept as JSON.
+type legacyEventStore struct {
+	events []event.Event
+}
+
+func (s *legacyEventStore) Add(e event.Event) error {
+	s.events = append(s.events, e)
+	return nil
+}
+
+func (s *legacyEventStore) Restore(time uint64, handleFunc event.ReadEventHandleFunc) error {
+	for _, v := range s.events {
+		if v.Time() > time {
+			continue
+		}
+		if err := handleFunc(v); nil != err {
+			return err
+		}
+	}
+	return nil
+}
+
+// playLegacyGame plays with only the events the game had before events got
+// a schema version, and returns them as they were stored back then.
+func playLegacyGame(t *testing.T, g *Instance) []event.Event {
+	t.Helper()
+	for i := 0; i < 3; i++ {
+		must(t, g.Execute(g.NextTurn()))
+	}
+	must(t, g.Execute(g.SetPolicy("collect food")))
+	for i := 0; i < 2; i++ {
+		must(t, g.Execute(g.NextTurn()))
+	}
+	root := g.GetGameStore().CurrentBranch
+	g.Dispatch(g.Windback(3))
+	newBranchEvent := g.NewBranch()
+	g.Dispatch(newBranchEvent)
+	must(t, g.Execute(g.SetBranch(newBranchEvent.NewBranchID)))
+	g.Dispatch(g.Windback(3))
+	must(t, g.Execute(g.SetPolicy("collect resources")))
+	must(t, g.Execute(g.NextTurn()))
+	must(t, g.Execute(g.SetScreen("main")))
+	must(t, g.Execute(g.SetSelectedValue("food")))
+	must(t, g.Execute(g.SetBranch(root)))
+
+	events := []event.Event{}
+	must(t, g.EventStore.Restore(^uint64(0), func(e event.Event) error {
+		// Old events have neither a version nor a chain hash
+		if field := reflect.ValueOf(e).Elem().FieldByName("EventSchema"); field.IsValid() {
+			field.Set(reflect.ValueOf(EventSchema{}))
+		}
+		events = append(events, e)
+		return nil
+	}))
+	return events
+}
+
+// testSaveState is what a loaded save is compared on.
+type testSaveState struct {
+	Branches int
+	Turn uint64
+	Policies []string
+	Food float64
+}
+
+func saveState(g *Instance) testSaveState {
+	store := g.GetCurrentBranchStore()
+	state := store.States()[len(store.States())-1]
+	return testSaveState{len(g.GetTimeLineStore().Branches), store.Turn, state.ActivePolicies, store.Values["food"]}
+}
+
+func TestLegacySaveMigrates(t *testing.T) {
+	gameData := testData(t)
+	g := newTestGame(t, filepath.Join(t.TempDir(), "played"), gameData)
+	events := playLegacyGame(t, g)
+	want := saveState(g)
+	must(t, g.Close())
+
+	fileName := filepath.Join(t.TempDir(), "legacy")
+	db, err := bolt.Open(fileName+".db", 0600, nil)
+	must(t, err)
+	must(t, NewChainedEventStore(db).MigrateLegacy(&legacyEventStore{events}))
+	must(t, db.Close())
+
+	for i := 0; i < 2; i++ {
+		g, err := NewGame(fileName, gameData)
+		must(t, err)
+		if g.Chain.Modified {
+			t.Error("migrated save marked as modified")
+		}
+		if got := saveState(g); !reflect.DeepEqual(got, want) {
+			t.Errorf("loaded %+v, want %+v", got, want)
+		}
+		// Events added after the migration are chained
+		must(t, g.Execute(g.SetSelectedValue("health")))
+		must(t, g.Close())
+	}
+}
+
+// corpusSaves holds the state of every save in testdata/saves. played was
+// written by the game with snapshots, migrated was migrated from the event
+// library and played on afterwards.
+var corpusSaves = map[string]testSaveState{
+	"played": {2, 6, []string{"collect resources"}, 2.805938},
+	"migrated": {2, 6, []string{"collect food"}, 10.405937999999999},
+}
+
+func TestCorpusSavesLoad(t *testing.T) {
+	gameData := testData(t)
+	files, err := os.ReadDir(filepath.Join("testdata", "saves"))
+	must(t, err)
+	if len(files) == 0 {
+		t.Fatal("no saves in testdata/saves")
+	}
+	for _, v := range files {
+		name := strings.TrimSuffix(v.Name(), ".db")
+		t.Run(name, func(t *testing.T) {
+			want, ok := corpusSaves[name]
+			if !ok {
+				t.Fatal("the save has no expected state")
+			}
+			g, err := NewGame(copyTestData(t, "saves", name), gameData)
+			must(t, err)
+			defer g.Close()
+			if g.Chain.Modified {
+				t.Error("save marked as modified")
+			}
+			if got := saveState(g); !reflect.DeepEqual(got, want) {
+				t.Errorf("loaded %+v, want %+v", got, want)
+			}
+		})
+	}
+}
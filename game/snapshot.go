@@ -0,0 +1,393 @@
+package game
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/boltdb/bolt"
+	"github.com/pkartner/event"
+)
+
+// DefaultSnapshotInterval is the number of events between snapshots.
+const DefaultSnapshotInterval = 100
+
+// SnapshotVersion changes whenever the layout of the snapshot changes,
+// snapshots of another version are ignored.
+const SnapshotVersion = 2
+
+// snapshotsKept is the number of snapshots kept in the save.
+const snapshotsKept = 3
+
+var snapshotBucket = []byte("timeline_snapshots")
+
+type storeSnapshot struct {
+	Store *BranchStore
+	LastEvent event.ID
+}
+
+type branchText struct {
+	Branch event.ID
+	Text string
+}
+
+type nodeText struct {
+	Node Node
+	Text string
+}
+
+// gameSnapshot holds the GameStore, the maps keyed by ids are stored as
+// sorted lists.
+type gameSnapshot struct {
+	Store GameStore
+	BranchNames []branchText
+	BranchNotes []branchText
+	TurnNotes []nodeText
+	ArchivedBranches []event.ID
+	DeletedBranches []event.ID
+}
+
+// Snapshot is the state of the timeline and every branch after EventCount
+// events, the last of them being LastEvent. ChainHash ties the snapshot to
+// the event chain, a snapshot edited outside the game no longer matches it.
+type Snapshot struct {
+	Version uint32
+	EventCount uint64
+	LastEvent event.ID
+	ChainHash string
+	Game gameSnapshot
+	Branches []event.Branch
+	Stores []storeSnapshot
+	RewindStores []storeSnapshot
+	Projections map[string]json.RawMessage
+}
+
+func lessID(a event.ID, b event.ID) bool {
+	return bytes.Compare(a[:], b[:]) < 0
+}
+
+func sortedIDs(set map[event.ID]struct{}) []event.ID {
+	ids := []event.ID{}
+	for k := range set {
+		ids = append(ids, k)
+	}
+	sort.Slice(ids, func(i, j int) bool { return lessID(ids[i], ids[j]) })
+	return ids
+}
+
+func sortedBranchTexts(texts map[event.ID]string) []branchText {
+	list := []branchText{}
+	for k, v := range texts {
+		list = append(list, branchText{k, v})
+	}
+	sort.Slice(list, func(i, j int) bool { return lessID(list[i].Branch, list[j].Branch) })
+	return list
+}
+
+func newGameSnapshot(store *GameStore) gameSnapshot {
+	snapshot := gameSnapshot{
+		Store: *store,
+		BranchNames: sortedBranchTexts(store.BranchNames),
+		BranchNotes: sortedBranchTexts(store.BranchNotes),
+		ArchivedBranches: sortedIDs(store.ArchivedBranches),
+		DeletedBranches: sortedIDs(store.DeletedBranches),
+	}
+	for k, v := range store.TurnNotes {
+		snapshot.TurnNotes = append(snapshot.TurnNotes, nodeText{k, v})
+	}
+	sort.Slice(snapshot.TurnNotes, func(i, j int) bool {
+		a := snapshot.TurnNotes[i].Node
+		b := snapshot.TurnNotes[j].Node
+		if a.Branch != b.Branch {
+			return lessID(a.Branch, b.Branch)
+		}
+		return a.Turn < b.Turn
+	})
+	snapshot.Store.BranchNames = nil
+	snapshot.Store.BranchNotes = nil
+	snapshot.Store.TurnNotes = nil
+	snapshot.Store.ArchivedBranches = nil
+	snapshot.Store.DeletedBranches = nil
+	return snapshot
+}
+
+func (s *gameSnapshot) gameStore() *GameStore {
+	store := s.Store
+	fresh := NewGameStore()
+	store.BranchNames = fresh.BranchNames
+	store.BranchNotes = fresh.BranchNotes
+	store.TurnNotes = fresh.TurnNotes
+	store.ArchivedBranches = fresh.ArchivedBranches
+	store.DeletedBranches = fresh.DeletedBranches
+	for _, v := range s.BranchNames {
+		store.BranchNames[v.Branch] = v.Text
+	}
+	for _, v := range s.BranchNotes {
+		store.BranchNotes[v.Branch] = v.Text
+	}
+	for _, v := range s.TurnNotes {
+		store.TurnNotes[v.Node] = v.Text
+	}
+	for _, v := range s.ArchivedBranches {
+		store.ArchivedBranches[v] = struct{}{}
+	}
+	for _, v := range s.DeletedBranches {
+		store.DeletedBranches[v] = struct{}{}
+	}
+	return &store
+}
+
+func newStoreSnapshots(stores []event.Store) []storeSnapshot {
+	snapshots := []storeSnapshot{}
+	for k := range stores {
+		snapshot := storeSnapshot{
+			Store: GetBranchStore(&stores[k]),
+		}
+		if nil != stores[k].LastEvent {
+			snapshot.LastEvent = stores[k].LastEvent.ID()
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	return snapshots
+}
+
+// TakeSnapshot captures the current state.
+func (g *Instance) TakeSnapshot() (*Snapshot, error) {
+	projections, err := g.projectionStates()
+	if nil != err {
+		return nil, err
+	}
+	timeStore := g.GetTimeLineStore()
+	snapshot := Snapshot{
+		Version: SnapshotVersion,
+		EventCount: g.eventCount,
+		Game: newGameSnapshot(g.GetGameStore()),
+		Branches: append([]event.Branch{}, timeStore.Branches...),
+		Stores: newStoreSnapshots(timeStore.Stores),
+		RewindStores: newStoreSnapshots(timeStore.RewindStores),
+		Projections: projections,
+	}
+	if nil != g.Dispatcher.Store.LastEvent {
+		snapshot.LastEvent = g.Dispatcher.Store.LastEvent.ID()
+	}
+	return &snapshot, nil
+}
+
+// chainHash hashes the snapshot together with the head of the event chain
+// after EventCount events.
+func (s *Snapshot) chainHash(chain *ChainedEventStore) (string, error) {
+	head, ok := chain.HeadAfter(s.EventCount)
+	if !ok {
+		return "", fmt.Errorf("The event chain after %d events isn't known", s.EventCount)
+	}
+	unhashed := *s
+	unhashed.ChainHash = ""
+	data, err := json.Marshal(unhashed)
+	if nil != err {
+		return "", err
+	}
+	hash := sha256.New()
+	hash.Write([]byte(head))
+	hash.Write(data)
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func snapshotKey(eventCount uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, eventCount)
+	return key
+}
+
+// WriteSnapshot stores a snapshot of the current state in the save and
+// drops the oldest snapshots.
+func (g *Instance) WriteSnapshot() error {
+	snapshot, err := g.TakeSnapshot()
+	if nil != err {
+		return err
+	}
+	snapshot.ChainHash, err = snapshot.chainHash(g.Chain)
+	if nil != err {
+		return err
+	}
+	data, err := json.Marshal(snapshot)
+	if nil != err {
+		return err
+	}
+	return g.DB.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(snapshotBucket)
+		if nil != err {
+			return err
+		}
+		if err := bucket.Put(snapshotKey(g.eventCount), data); nil != err {
+			return err
+		}
+		keys := [][]byte{}
+		cursor := bucket.Cursor()
+		for k, _ := cursor.Last(); k != nil; k, _ = cursor.Prev() {
+			keys = append(keys, append([]byte{}, k...))
+		}
+		for k := snapshotsKept; k < len(keys); k++ {
+			if err := bucket.Delete(keys[k]); nil != err {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// snapshots returns the snapshots in the save, newest first. Snapshots that
+// can't be read are skipped.
+func (g *Instance) snapshots() ([]*Snapshot, error) {
+	snapshots := []*Snapshot{}
+	err := g.DB.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(snapshotBucket)
+		if nil == bucket {
+			return nil
+		}
+		cursor := bucket.Cursor()
+		for k, v := cursor.Last(); k != nil; k, v = cursor.Prev() {
+			snapshot := Snapshot{}
+			if err := json.Unmarshal(v, &snapshot); nil != err {
+				fmt.Println(fmt.Sprintf("Skipping unreadable snapshot: %s", err))
+				continue
+			}
+			if snapshot.Version != SnapshotVersion {
+				continue
+			}
+			snapshots = append(snapshots, &snapshot)
+		}
+		return nil
+	})
+	return snapshots, err
+}
+
+// snapshotIfDue writes a snapshot every SnapshotInterval events.
+func (g *Instance) snapshotIfDue() {
+	if g.SnapshotInterval == 0 || g.eventCount%g.SnapshotInterval != 0 {
+		return
+	}
+	if err := g.WriteSnapshot(); nil != err {
+		fmt.Println(fmt.Sprintf("Could not write snapshot: %s", err))
+	}
+}
+
+// load restores the save, when useSnapshots is set it starts from the
+// latest snapshot that matches the event log and has the state of every
+// projection, and only handles the events after it.
+func (g *Instance) load(useSnapshots bool) error {
+	events := []event.Event{}
+	err := g.EventStore.Restore(^uint64(0), func(e event.Event) error {
+		events = append(events, e)
+		return nil
+	})
+	if nil != err {
+		return err
+	}
+	g.eventCount = uint64(len(events))
+
+	for _, v := range g.projections {
+		v.Reset()
+	}
+	start := 0
+	if useSnapshots {
+		snapshots, err := g.snapshots()
+		if nil != err {
+			return err
+		}
+		for _, v := range snapshots {
+			if v.EventCount == 0 || v.EventCount > uint64(len(events)) || events[v.EventCount-1].ID() != v.LastEvent {
+				continue
+			}
+			if !g.hasProjectionStates(v) {
+				continue
+			}
+			if hash, err := v.chainHash(g.Chain); nil != err || hash != v.ChainHash {
+				fmt.Println(fmt.Sprintf("The snapshot after %d events doesn't match the event chain", v.EventCount))
+				if err := g.Chain.MarkModified(); nil != err {
+					return err
+				}
+				continue
+			}
+			fmt.Println(fmt.Sprintf("Loading snapshot after %d events", v.EventCount))
+			if err := g.restoreProjections(v); nil != err {
+				return err
+			}
+			g.applySnapshot(v, events[:v.EventCount])
+			start = int(v.EventCount)
+			break
+		}
+	}
+
+	for _, v := range events[start:] {
+		if err := g.Dispatcher.Handle(v); nil != err {
+			return err
+		}
+		g.applyProjections(v)
+	}
+	return nil
+}
+
+func (g *Instance) applySnapshot(snapshot *Snapshot, events []event.Event) {
+	byID := map[event.ID]event.Event{}
+	for _, v := range events {
+		byID[v.ID()] = v
+	}
+	restoreStores := func(snapshots []storeSnapshot) []event.Store {
+		stores := make([]event.Store, len(snapshots))
+		for k, v := range snapshots {
+			stores[k].Attributes = v.Store
+			stores[k].LastEvent = byID[v.LastEvent]
+		}
+		return stores
+	}
+
+	timeStore := g.GetTimeLineStore()
+	timeStore.Attributes = snapshot.Game.gameStore()
+	timeStore.Branches = append([]event.Branch{}, snapshot.Branches...)
+	timeStore.BranchDictionary = map[event.ID]int{}
+	for k, v := range timeStore.Branches {
+		timeStore.BranchDictionary[v.BranchID] = k
+	}
+	timeStore.Stores = restoreStores(snapshot.Stores)
+	timeStore.RewindStores = restoreStores(snapshot.RewindStores)
+	g.Dispatcher.Store.LastEvent = byID[snapshot.LastEvent]
+}
+
+// CheckSnapshot loads the save both from its latest snapshot and by handling
+// every event again, and returns an error when the states differ.
+func CheckSnapshot(fileName string, gameData *Data) error {
+	replayed, err := ReplayGame(fileName, gameData)
+	if nil != err {
+		return err
+	}
+	replayedState, err := marshalSnapshot(replayed)
+	replayed.Close()
+	if nil != err {
+		return err
+	}
+	loaded, err := NewGame(fileName, gameData)
+	if nil != err {
+		return err
+	}
+	loadedState, err := marshalSnapshot(loaded)
+	loaded.Close()
+	if nil != err {
+		return err
+	}
+	if !bytes.Equal(replayedState, loadedState) {
+		return fmt.Errorf("The snapshot of %s doesn't match replaying its events", fileName)
+	}
+	return nil
+}
+
+func marshalSnapshot(g *Instance) ([]byte, error) {
+	snapshot, err := g.TakeSnapshot()
+	if nil != err {
+		return nil, err
+	}
+	return json.Marshal(snapshot)
+}
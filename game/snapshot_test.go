@@ -0,0 +1,121 @@
+package game
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/boltdb/bolt"
+	"github.com/pkartner/event"
+)
+
+func testData(t *testing.T) *Data {
+	t.Helper()
+	return LoadData([]DataRoot{{Name: "data", FS: os.DirFS(filepath.Join("..", "data"))}}, "")
+}
+
+// newTestGame starts a new save with reproducible event times.
+func newTestGame(t *testing.T, fileName string, gameData *Data) *Instance {
+	t.Helper()
+	g, err := NewGame(fileName, gameData)
+	must(t, err)
+	g.Clock = &StepClock{Time: 1, Step: 1}
+	g.Dispatch(event.NewBranch(0, event.ZeroID(), event.ZeroID(), g.Clock.Now(), g.IDs.NextIDPart()))
+	if err := g.Execute(g.SetBranch(g.GetTimeLineStore().Branches[0].BranchID)); nil != err {
+		t.Fatal(err)
+	}
+	return g
+}
+
+func must(t *testing.T, err error) {
+	t.Helper()
+	if nil != err {
+		t.Fatal(err)
+	}
+}
+
+// playTestGame plays a few turns on the root branch and continues on a
+// branch split off in the past.
+func playTestGame(t *testing.T, g *Instance) {
+	t.Helper()
+	for i := 0; i < 3; i++ {
+		must(t, g.EndTurn())
+	}
+	must(t, g.ChangePolicy("collect food"))
+	for i := 0; i < 3; i++ {
+		must(t, g.EndTurn())
+	}
+	must(t, g.JumpTo(Node{g.GetGameStore().CurrentBranch, 4}))
+	must(t, g.ChangePolicy("collect resources"))
+	for i := 0; i < 2; i++ {
+		must(t, g.EndTurn())
+	}
+}
+
+func TestSnapshotLoadMatchesReplay(t *testing.T) {
+	gameData := testData(t)
+	fileName := filepath.Join(t.TempDir(), "save")
+	g := newTestGame(t, fileName, gameData)
+	g.SnapshotInterval = 5
+	playTestGame(t, g)
+	if len(g.GetTimeLineStore().Branches) != 2 {
+		t.Fatalf("got %d branches, want 2", len(g.GetTimeLineStore().Branches))
+	}
+	snapshots, err := g.snapshots()
+	must(t, err)
+	if len(snapshots) == 0 {
+		t.Fatal("no snapshot was written")
+	}
+	must(t, g.Close())
+
+	must(t, CheckSnapshot(fileName, gameData))
+}
+
+func TestEditedSnapshotIsRejected(t *testing.T) {
+	gameData := testData(t)
+	tests := []struct {
+		name string
+		edit bool
+	}{
+		{"unchanged", false},
+		{"edited energy", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fileName := filepath.Join(t.TempDir(), "save")
+			g := newTestGame(t, fileName, gameData)
+			g.SnapshotInterval = 5
+			playTestGame(t, g)
+			energy := g.GetGameStore().TemporalEnergy
+			must(t, g.Close())
+
+			if test.edit {
+				tamperTestChain(t, fileName+".db", func(tx *bolt.Tx) error {
+					bucket := tx.Bucket(snapshotBucket)
+					k, v := bucket.Cursor().Last()
+					snapshot := Snapshot{}
+					if err := json.Unmarshal(v, &snapshot); nil != err {
+						return err
+					}
+					snapshot.Game.Store.TemporalEnergy = energy+100
+					data, err := json.Marshal(snapshot)
+					if nil != err {
+						return err
+					}
+					return bucket.Put(append([]byte{}, k...), data)
+				})
+			}
+
+			g, err := NewGame(fileName, gameData)
+			must(t, err)
+			defer g.Close()
+			if g.Chain.Modified != test.edit {
+				t.Errorf("modified is %t, want %t", g.Chain.Modified, test.edit)
+			}
+			if g.GetGameStore().TemporalEnergy != energy {
+				t.Errorf("temporal energy is %v, want %v", g.GetGameStore().TemporalEnergy, energy)
+			}
+		})
+	}
+}
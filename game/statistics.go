@@ -0,0 +1,220 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/boltdb/bolt"
+	"github.com/pkartner/event"
+)
+
+const StatisticsProjectionName = "statistics"
+
+// maxIdleTime is the longest gap between two events, in seconds, that
+// counts as time played.
+const maxIdleTime = 5*60
+
+// DefaultScenarioName is used for scenarios without a name.
+const DefaultScenarioName = "default"
+
+type ScenarioResults struct {
+	Wins uint64 `json:"wins"`
+	Losses uint64 `json:"losses"`
+}
+
+// Statistics are counted from the events of a save, TimePlayed is in
+// seconds.
+type Statistics struct {
+	TurnsPlayed uint64 `json:"turns_played"`
+	TurnsRewound uint64 `json:"turns_rewound"`
+	BranchesCreated uint64 `json:"branches_created"`
+	PoliciesToggled uint64 `json:"policies_toggled"`
+	TimePlayed uint64 `json:"time_played"`
+	Scenarios map[string]ScenarioResults `json:"scenarios"`
+}
+
+// Add adds the other statistics to s.
+func (s *Statistics) Add(other *Statistics) {
+	s.TurnsPlayed += other.TurnsPlayed
+	s.TurnsRewound += other.TurnsRewound
+	s.BranchesCreated += other.BranchesCreated
+	s.PoliciesToggled += other.PoliciesToggled
+	s.TimePlayed += other.TimePlayed
+	if nil == s.Scenarios {
+		s.Scenarios = map[string]ScenarioResults{}
+	}
+	for k, v := range other.Scenarios {
+		results := s.Scenarios[k]
+		results.Wins += v.Wins
+		results.Losses += v.Losses
+		s.Scenarios[k] = results
+	}
+}
+
+// Results returns the wins and losses over all scenarios.
+func (s *Statistics) Results() ScenarioResults {
+	total := ScenarioResults{}
+	for _, v := range s.Scenarios {
+		total.Wins += v.Wins
+		total.Losses += v.Losses
+	}
+	return total
+}
+
+// Summary describes the statistics on a single line.
+func (s *Statistics) Summary() string {
+	results := s.Results()
+	return fmt.Sprintf("%d turns, %d rewound, %d branches, %d toggles, %dm played, %d won, %d lost",
+		s.TurnsPlayed,
+		s.TurnsRewound,
+		s.BranchesCreated,
+		s.PoliciesToggled,
+		s.TimePlayed/60,
+		results.Wins,
+		results.Losses,
+	)
+}
+
+// ScenarioLines describes the wins and losses of every scenario.
+func (s *Statistics) ScenarioLines() []string {
+	names := []string{}
+	for k := range s.Scenarios {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	lines := []string{}
+	for _, v := range names {
+		lines = append(lines, fmt.Sprintf("%s: %d won, %d lost", v, s.Scenarios[v].Wins, s.Scenarios[v].Losses))
+	}
+	return lines
+}
+
+func (s *Statistics) String() string {
+	return strings.Join(append([]string{s.Summary()}, s.ScenarioLines()...), "\n")
+}
+
+// StatisticsProjection counts the statistics of a save from its events.
+type StatisticsProjection struct {
+	Statistics Statistics
+	LastTime uint64
+	LastTurn uint64
+	// Outcomes holds the last known outcome of every branch, so a win or
+	// loss is only counted once.
+	Outcomes map[string]uint8
+}
+
+func NewStatisticsProjection() *StatisticsProjection {
+	p := &StatisticsProjection{}
+	p.Reset()
+	return p
+}
+
+func (p *StatisticsProjection) Name() string {
+	return StatisticsProjectionName
+}
+
+func (p *StatisticsProjection) Reset() {
+	p.Statistics = Statistics{
+		Scenarios: map[string]ScenarioResults{},
+	}
+	p.LastTime = 0
+	p.LastTurn = 0
+	p.Outcomes = map[string]uint8{}
+}
+
+func (p *StatisticsProjection) Apply(g *Instance, e event.Event) {
+	stats := &p.Statistics
+	if p.LastTime != 0 && e.Time() > p.LastTime {
+		gap := e.Time()-p.LastTime
+		if gap > maxIdleTime {
+			gap = maxIdleTime
+		}
+		stats.TimePlayed += gap
+	}
+	p.LastTime = e.Time()
+
+	turn := p.LastTurn
+	if _, err := g.branchStore(g.GetGameStore().CurrentBranch); nil == err {
+		turn = g.GetRewindedBranchStore().Turn
+	}
+
+	switch e := e.(type) {
+	case *NextTurnEvent:
+		stats.TurnsPlayed++
+		store, err := g.branchStore(e.BranchID)
+		if nil != err {
+			break
+		}
+		key := e.BranchID.ToString()
+		if store.GameOver == 0 || p.Outcomes[key] == store.GameOver {
+			break
+		}
+		p.Outcomes[key] = store.GameOver
+		scenario := g.GameData.Scenario.Name
+		if scenario == "" {
+			scenario = DefaultScenarioName
+		}
+		results := stats.Scenarios[scenario]
+		if store.GameOver == GameWon {
+			results.Wins++
+		} else {
+			results.Losses++
+		}
+		stats.Scenarios[scenario] = results
+	case *SetPolicyEvent:
+		stats.PoliciesToggled++
+	case *event.NewBranchEvent:
+		// Creating the root branch isn't creating a branch to the player
+		if len(g.GetTimeLineStore().Branches) > 1 {
+			stats.BranchesCreated++
+		}
+	case *event.WindbackEvent:
+		if turn < p.LastTurn {
+			stats.TurnsRewound += p.LastTurn-turn
+		}
+	}
+	p.LastTurn = turn
+}
+
+func (p *StatisticsProjection) MarshalState() ([]byte, error) {
+	return json.Marshal(p)
+}
+
+func (p *StatisticsProjection) UnmarshalState(data []byte) error {
+	p.Reset()
+	return json.Unmarshal(data, p)
+}
+
+// SaveSummary is what the save screen shows of a save without opening it.
+type SaveSummary struct {
+	Statistics Statistics
+	// Modified is set when the events of the save were changed outside the
+	// game.
+	Modified bool
+}
+
+// ReadSaveSummary loads the save once to count its statistics and verify its
+// events. The save is opened read only and nothing is dispatched, so listing
+// saves doesn't migrate them or write snapshots.
+func ReadSaveSummary(fileName string, gameData *Data) (*SaveSummary, error) {
+	db, err := bolt.Open(fileName+".db", 0600, &bolt.Options{ReadOnly: true})
+	if nil != err {
+		return nil, err
+	}
+	defer db.Close()
+	projection := NewStatisticsProjection()
+	g := newInstance(db, gameData, []Projection{projection})
+	g.SnapshotInterval = 0
+	if !g.Chain.Migrated() {
+		g.EventStore = event.NewBoltEventStore(db)
+	}
+	if err := g.load(true); nil != err {
+		return nil, fmt.Errorf("Could not load %s: %s", fileName, err)
+	}
+	return &SaveSummary{
+		Statistics: projection.Statistics,
+		Modified: g.Chain.Modified,
+	}, nil
+}
@@ -0,0 +1,68 @@
+package game
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/boltdb/bolt"
+)
+
+func TestReadSaveSummary(t *testing.T) {
+	gameData := testData(t)
+	tests := []struct {
+		name string
+		tamper func(t *testing.T, fileName string)
+		modified bool
+		fails bool
+	}{
+		{"unchanged", nil, false, false},
+		{"modified", func(t *testing.T, fileName string) {
+			tamperTestChain(t, fileName+".db", func(tx *bolt.Tx) error {
+				return tx.Bucket(eventBucket).Delete(eventKey(3))
+			})
+		}, true, false},
+		{"unknown event", func(t *testing.T, fileName string) {
+			tamperTestChain(t, fileName+".db", func(tx *bolt.Tx) error {
+				return putEvent(tx, storedEvent{"no_such_event", []byte("{}")})
+			})
+		}, false, true},
+		{"not a save", func(t *testing.T, fileName string) {
+			must(t, os.WriteFile(fileName+".db", []byte("not a save"), 0600))
+		}, false, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fileName := filepath.Join(t.TempDir(), "save")
+			g := newTestGame(t, fileName, gameData)
+			playTestGame(t, g)
+			must(t, g.Close())
+			if nil != test.tamper {
+				test.tamper(t, fileName)
+			}
+
+			before, err := os.ReadFile(fileName+".db")
+			must(t, err)
+			summary, err := ReadSaveSummary(fileName, gameData)
+			after, readErr := os.ReadFile(fileName+".db")
+			must(t, readErr)
+			if !bytes.Equal(before, after) {
+				t.Error("reading the summary changed the save")
+			}
+			if test.fails {
+				if nil == err {
+					t.Fatal("corrupt save was read")
+				}
+				return
+			}
+			must(t, err)
+			if summary.Modified != test.modified {
+				t.Errorf("modified is %t, want %t", summary.Modified, test.modified)
+			}
+			if summary.Statistics.TurnsPlayed == 0 {
+				t.Error("no turns counted")
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package game
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/pkartner/event"
 )
@@ -15,6 +16,54 @@ type GameStore struct {
 	CurrentScreen string
 	SelectedValue string
 	Rewind bool
+	Mods []string
+	CompareBranch event.ID
+	BranchNames map[event.ID]string
+	BranchNotes map[event.ID]string
+	TurnNotes map[Node]string
+	Bookmarks []Node
+	ArchivedBranches map[event.ID]struct{}
+	DeletedBranches map[event.ID]struct{}
+	TemporalEnergy float64
+	// Discoveries are known on every branch, in the order they were made.
+	Discoveries []string
+	// Timeline is won or lost once the timeline objectives of the scenario
+	// are, it ends the game on every branch.
+	Timeline uint8
+}
+
+// Node is a turn on a branch of the timeline.
+type Node struct {
+	Branch event.ID
+	Turn uint64
+}
+
+func NewGameStore() *GameStore {
+	return &GameStore{
+		BranchNames: map[event.ID]string{},
+		BranchNotes: map[event.ID]string{},
+		TurnNotes: map[Node]string{},
+		ArchivedBranches: map[event.ID]struct{}{},
+		DeletedBranches: map[event.ID]struct{}{},
+	}
+}
+
+// Hidden returns if the branch shouldn't be drawn on the timeline.
+func (s *GameStore) Hidden(branchID event.ID) bool {
+	if _, ok := s.DeletedBranches[branchID]; ok {
+		return true
+	}
+	_, ok := s.ArchivedBranches[branchID]
+	return ok
+}
+
+func (s *GameStore) Bookmarked(node Node) bool {
+	for _, v := range s.Bookmarks {
+		if v == node {
+			return true
+		}
+	}
+	return false
 }
 
 type BranchStore struct {
@@ -24,6 +73,53 @@ type BranchStore struct {
 	GameOver uint8
 	ActivePolicies map[string]struct{}
 	Turn uint64
+	StoryBeats []string
+	History []TurnState
+}
+
+// TurnState is the state of a branch at the start of a turn together with
+// the policies that were active when the turn ended.
+type TurnState struct {
+	Turn uint64
+	Values ValueMap
+	ActivePolicies []string
+	GameOver uint8
+}
+
+// States returns the state of every turn of the branch, ending with the
+// current turn.
+func (s *BranchStore) States() []TurnState {
+	states := append([]TurnState{}, s.History...)
+	return append(states, s.turnState())
+}
+
+func (s *BranchStore) turnState() TurnState {
+	values := ValueMap{}
+	for k, v := range s.Values {
+		values[k] = v
+	}
+	policies := []string{}
+	for k := range s.ActivePolicies {
+		policies = append(policies, k)
+	}
+	sort.Strings(policies)
+	return TurnState{
+		Turn: s.Turn,
+		Values: values,
+		ActivePolicies: policies,
+		GameOver: s.GameOver,
+	}
+}
+
+// copy returns the state with its own values and policies.
+func (s TurnState) copy() TurnState {
+	values := ValueMap{}
+	for k, v := range s.Values {
+		values[k] = v
+	}
+	s.Values = values
+	s.ActivePolicies = append([]string{}, s.ActivePolicies...)
+	return s
 }
 
 // GetBranchID TODO
@@ -36,11 +132,19 @@ func(s *BranchStore) SetBranchID(id event.ID) {
     s.BranchID = id
 }
 
-func NewBranchStoreFunc(values ValueMap) func() event.BranchStore {
+func NewBranchStoreFunc(gameData *Data) func() event.BranchStore {
 	return func () event.BranchStore {
 		store := &BranchStore{}
 		store.ActivePolicies = map[string]struct{}{}
-		store.Values = values
+		for _, v := range gameData.Scenario.StartPolicies {
+			ActivatePolicy(store.ActivePolicies, gameData.Policies, v)
+		}
+		ApplyPolicyLocks(store.ActivePolicies, gameData.Policies, &gameData.Scenario, 0)
+		// Every branch gets its own values, the data is shared by every game
+		store.Values = ValueMap{}
+		for k, v := range gameData.Scenario.StartValues {
+			store.Values[k] = v
+		}
 		return store
 	}
 }
@@ -2,11 +2,12 @@ package main
 
 import (
 	"fmt"
-	"io/ioutil"
-	"encoding/json"
+	"flag"
+	"io"
 	"os"
+	"path/filepath"
 	"time"
-	"sort"
+	"strings"
 
 	"github.com/faiface/pixel"
 	"github.com/faiface/pixel/pixelgl"
@@ -30,45 +31,126 @@ func SaveGameFileNames () []string {
 	}
 }
 
-func PolicyClickHandler(policy string) game.GuiEventHandler {
+func PolicyClickHandler(s *Session, policy string) game.GuiEventHandler {
 	return func(interface{}) {
 		fmt.Println("Policy set event fired policy: "+ policy)
-		game.Current.Dispatcher.Dispatch(game.SetPolicy(policy))
+		s.ReportError(s.Game.ChangePolicy(policy))
 	}
 }
 
-func MenuButtonHandler(screen string) game.GuiEventHandler {
+func MenuButtonHandler(s *Session, screen string) game.GuiEventHandler {
 	return func(interface{}) {
 		fmt.Println(fmt.Sprintf("Button %s pressed", screen))
-		game.Current.Dispatcher.Dispatch(game.SetScreen(screen))
+		s.Game.Dispatch(s.Game.SetScreen(screen))
 	}
 }
 
-func ValueClickHandler(value string) game.GuiEventHandler {
+func ValueClickHandler(s *Session, value string) game.GuiEventHandler {
 	return func(interface{}) {
-		game.Current.Dispatcher.Dispatch(game.SetSelectedValue(value))
+		s.ReportError(s.Game.Execute(s.Game.SetSelectedValue(value)))
 	}
 }
 
-func ValueStringProvider(value string) game.GuiStringProviderFunc {
+func ValueStringProvider(s *Session, value string) game.GuiStringProviderFunc {
 	return func() string{
-		valueNumber := game.Current.GetRewindedBranchStore().Values[value]
-		return fmt.Sprintf(value + ": %.2f", valueNumber)
+		if s.Game.ValueHidden(value) {
+			return fmt.Sprintf("%s: ???", s.Data.ValueName(value))
+		}
+		valueNumber := s.Game.GetRewindedBranchStore().Values[value]
+		return fmt.Sprintf("%s: %.2f", s.Data.ValueName(value), valueNumber)
+	}
+}
+
+func SelectedValueDescriptionProvider(s *Session) game.GuiStringProviderFunc {
+	return func() string{
+		value := s.Game.GetGameStore().SelectedValue
+		if value == "" || s.Game.ValueHidden(value) {
+			return ""
+		}
+		return s.Data.ValueDescription(value)
+	}
+}
+
+func StatusMessageProvider(s *Session) game.GuiStringProviderFunc {
+	return func() string{
+		if nil != s.Input {
+			return s.Input.Prompt+": "+s.Input.Text+"_"
+		}
+		return s.Status
+	}
+}
+
+func NoticeProvider(s *Session) game.GuiStringProviderFunc {
+	return func() string{
+		return s.Notice
+	}
+}
+
+// AchievementsProvider lists every achievement, marking the ones unlocked in
+// the profile.
+func AchievementsProvider(s *Session) game.GuiStringProviderFunc {
+	return func() string{
+		lines := []string{}
+		for _, v := range s.Data.AchievementIDs() {
+			mark := "[ ]"
+			if nil != s.Profile {
+				if _, ok := s.Profile.Achievements[v]; ok {
+					mark = "[x]"
+				}
+			}
+			lines = append(lines, fmt.Sprintf("%s %s", mark, s.Data.AchievementName(v)))
+			lines = append(lines, "    "+s.Data.AchievementDescription(v))
+		}
+		return strings.Join(lines, "\n")
 	}
 }
 
-func SaveGameListClickedHandler(gameStarted *bool, gameData *game.Data) game.GuiEventHandler {
+func TemporalEnergyProvider(s *Session) game.GuiStringProviderFunc {
+	return func() string{
+		energy := s.Data.Scenario.TemporalEnergy
+		if nil == energy {
+			return ""
+		}
+		return fmt.Sprintf("%s: %.1f/%.1f", s.Data.Text("ui.temporal_energy", "Temporal energy"), s.Game.GetGameStore().TemporalEnergy, energy.Max)
+	}
+}
+
+// NotesProvider shows the notes of the current branch and turn.
+func NotesProvider(s *Session) game.GuiStringProviderFunc {
+	return func() string{
+		g := s.Game
+		node := g.CurrentNode()
+		lines := []string{}
+		if note := g.GetGameStore().BranchNotes[node.Branch]; note != "" {
+			lines = append(lines, game.WrapText(note, 50))
+		}
+		if note := g.TurnNote(node.Branch, node.Turn); note != "" {
+			lines = append(lines, game.WrapText(fmt.Sprintf("%03d %s", node.Turn, note), 50))
+		}
+		return strings.Join(lines, "\n")
+	}
+}
+
+func SaveGameListClickedHandler(gameData *game.Data, dir string, profile *game.Profile, projections game.ProjectionFactory, start func(*game.Instance)) game.GuiEventHandler {
 	return func(value interface{}) {
 		gameClicked, ok := value.(*game.SaveGameClicked)
 		if !ok {
 			panic("Interface not of type SaveGameClicked")
 		}
-		dir := "save"
-		databaseFileName := dir+"/"+gameClicked.Filename+".db"
+		databaseFileName := filepath.Join(dir, gameClicked.Filename+".db")
 		if gameClicked.Remake {
 			if _, err := os.Stat(databaseFileName); !os.IsNotExist(err) {
+				// The statistics of the overwritten save stay in the profile,
+				// a save that can't be loaded is overwritten without them
+				summary, err := game.ReadSaveSummary(filepath.Join(dir, gameClicked.Filename), gameData)
+				if nil != err {
+					fmt.Println(err)
+				} else if err := profile.Retire(&summary.Statistics); nil != err {
+					fmt.Println(err)
+				}
 				if err := os.Remove(databaseFileName); nil != err {
-					panic(err)
+					fmt.Println(err)
+					return
 				}
 			}
 		}
@@ -76,85 +158,304 @@ func SaveGameListClickedHandler(gameStarted *bool, gameData *game.Data) game.Gui
 		if _, err := os.Stat(databaseFileName); os.IsNotExist(err) {
 			newGame = true
 		}
-		os.Mkdir(dir, os.ModePerm)
-		game.NewGame(dir+"/"+gameClicked.Filename, gameData)
+		os.MkdirAll(dir, os.ModePerm)
+		g, err := game.NewGame(filepath.Join(dir, gameClicked.Filename), gameData, projections()...)
+		if nil != err {
+			fmt.Println(err)
+			return
+		}
 		if newGame {
-			game.Current.Dispatcher.Dispatch(event.NewBranch(0, event.ZeroID(), event.ZeroID(), uint64(time.Now().Unix()), 0))
-			branchID := game.Current.GetTimeLineStore().Branches[0].BranchID
-			game.Current.Dispatcher.Dispatch(game.SetBranch(branchID))
-			game.Current.Dispatcher.Dispatch(game.SetScreen("main"))
+			g.Dispatch(event.NewBranch(0, event.ZeroID(), event.ZeroID(), g.Clock.Now(), g.IDs.NextIDPart()))
+			branchID := g.GetTimeLineStore().Branches[0].BranchID
+			g.Dispatch(g.SetBranch(branchID))
+			g.Dispatch(g.SetMods(gameData.Mods))
+			g.DispatchStoryBeats()
+			g.DispatchDiscoveries()
+			screen := "main"
+			if nil != gameData.Scenario.Intro {
+				screen = "intro"
+			}
+			g.Dispatch(g.SetScreen(screen))
+		} else {
+			// Mods added since the save was played can have discoveries
+			// whose condition already holds
+			g.DispatchDiscoveries()
 		}
-		*gameStarted = true
+		start(g)
 	}
 }
 
-func EndturnHandler() game.GuiEventHandler {
+func EndturnHandler(s *Session) game.GuiEventHandler {
 	return func(interface{}) {
-		game.Current.Dispatcher.Dispatch(game.NextTurn())
+		s.ReportError(s.Game.EndTurn())
+	}
+}
+
+func StoryMessagesProvider(s *Session, count int) game.GuiStringProviderFunc {
+	return func() string{
+		beats := s.Game.GetRewindedBranchStore().StoryBeats
+		if len(beats) > count {
+			beats = beats[len(beats)-count:]
+		}
+		messages := []string{}
+		for _, v := range beats {
+			messages = append(messages, game.WrapText(s.Data.StoryBeatText(v), 50))
+		}
+		return strings.Join(messages, "\n\n")
+	}
+}
+
+// DiscoveriesProvider lists the last discoveries, they are known on every
+// branch.
+func DiscoveriesProvider(s *Session, count int) game.GuiStringProviderFunc {
+	return func() string{
+		discoveries := s.Game.GetGameStore().Discoveries
+		if len(discoveries) == 0 {
+			return ""
+		}
+		if len(discoveries) > count {
+			discoveries = discoveries[len(discoveries)-count:]
+		}
+		lines := []string{s.Data.Text("ui.discoveries", "Discoveries")+":"}
+		for _, v := range discoveries {
+			lines = append(lines, game.WrapText(s.Data.DiscoveryText(v), 50))
+		}
+		return strings.Join(lines, "\n")
 	}
 }
 
-func GotoBranch() game.GuiEventHandler {
+func StaticStringProvider(text string) game.GuiStringProviderFunc {
+	return func() string{
+		return text
+	}
+}
+
+func GotoBranch(s *Session) game.GuiEventHandler {
 	return func(arguments interface{}) {
 		a := arguments.(*game.TimelineClicked)
-		game.Current.Dispatcher.Dispatch(game.SetBranch(a.Branch))
-		lastID := game.Current.Dispatcher.Store.LastEvent.ID().IDPart()
-		game.Current.Dispatcher.Dispatch(event.Windback(a.Time, uint64(time.Now().Unix()), lastID+1))
+		if err := s.Game.JumpTo(game.Node{Branch: a.Branch, Turn: a.Time}); nil != err {
+			s.ReportError(err)
+			return
+		}
+		PruneBranches(s)
+	}
+}
+
+func NewBranchHandler(s *Session) game.GuiEventHandler {
+	return func(interface{}) {
+		if _, err := s.Game.SplitBranch(); nil != err {
+			s.ReportError(err)
+			return
+		}
+		PruneBranches(s)
+	}
+}
+
+func PruneBranches(s *Session) {
+	if !s.AutoPrune {
+		return
+	}
+	if pruned := s.Game.PruneLostBranches(); len(pruned) > 0 {
+		s.Status = fmt.Sprintf("Archived %d lost branches", len(pruned))
+	}
+}
 
+// markedBranch returns the branch marked by the player.
+func markedBranch(s *Session) (event.ID, error) {
+	branchID := s.Game.GetGameStore().CompareBranch
+	if branchID == event.ZeroID() {
+		return branchID, fmt.Errorf("Mark a branch first")
 	}
+	return branchID, nil
 }
 
-func NewBranchHandler() game.GuiEventHandler {
+// ArchiveBranchHandler archives the marked branch, or brings it back when
+// it is archived already.
+func ArchiveBranchHandler(s *Session) game.GuiEventHandler {
 	return func(interface{}) {
-		gameStore := game.Current.GetGameStore()
-		currentBranchID := gameStore.CurrentBranch
-		store := game.Current.GetRewindedStore()
-		branchStore := game.Current.GetRewindedBranchStore()
-
-		lastEventID := event.ID{}
-		if store.LastEvent != nil {
-			lastEventID = store.LastEvent.ID()
-		}
-		
-		currentTime := time.Now().Unix()
-		lastID := game.Current.Dispatcher.Store.LastEvent.ID().IDPart()
-		fmt.Println(fmt.Sprintf("Starting new branch at turn %d", branchStore.Turn))
-		newBranchEvent := event.NewBranch(branchStore.Turn, currentBranchID, lastEventID, uint64(currentTime), lastID+1)
-		fmt.Println(fmt.Sprintf("Prev branch id is: %s", currentBranchID.ToString()))
-		fmt.Println(fmt.Sprintf("New branch id is: %s", newBranchEvent.NewBranchID.ToString()))
-		fmt.Println(fmt.Sprintf("Currently we are rewinded: %t", gameStore.Rewind))
-		game.Current.Dispatcher.Dispatch(newBranchEvent)
-	}
-}
-
-func LoadData() *game.Data{
-	gameData := game.Data{}
-	data, err := ioutil.ReadFile("data/policies.json")
-	if err != nil {
-		panic (err)
-	}	
-	if err := json.Unmarshal(data, &gameData.Policies); err != nil {
-		panic(err)
+		g := s.Game
+		branchID, err := markedBranch(s)
+		if nil != err {
+			s.ReportError(err)
+			return
+		}
+		_, archived := g.GetGameStore().ArchivedBranches[branchID]
+		s.ReportError(g.Execute(g.ArchiveBranch(branchID, !archived)))
 	}
-	data, err = ioutil.ReadFile("data/values.json")
-	if err != nil {
-		panic (err)
-	}	
-	if err := json.Unmarshal(data, &gameData.Values); err != nil {
-		panic(err)
+}
+
+// DeleteBranchHandler deletes the marked branch and its sub branches after
+// the player confirms.
+func DeleteBranchHandler(s *Session) game.GuiEventHandler {
+	return func(interface{}) {
+		g := s.Game
+		branchID, err := markedBranch(s)
+		if nil != err {
+			s.ReportError(err)
+			return
+		}
+		deleteEvent := g.DeleteBranch(branchID)
+		if err := g.Validate(deleteEvent); nil != err {
+			s.ReportError(err)
+			return
+		}
+		timelineStore := g.GetTimeLineStore()
+		question := fmt.Sprintf("Delete %s and %d sub branches",
+			game.BranchLabel(timelineStore, branchID),
+			len(game.BranchDescendants(timelineStore, branchID))-1,
+		)
+		s.Confirm(question, func() {
+			if err := g.Execute(g.DeleteBranch(branchID)); nil != err {
+				s.ReportError(err)
+				return
+			}
+			// The branches left may decide the timeline
+			g.DispatchTimelineOutcome()
+		})
 	}
-	data, err = ioutil.ReadFile("data/scenario.json")
-	if err != nil {
-		panic (err)
+}
+
+func ShowArchivedHandler(s *Session) game.GuiEventHandler {
+	return func(interface{}) {
+		s.Timeline.ShowArchived = !s.Timeline.ShowArchived
 	}
-	if err := json.Unmarshal(data, &gameData.Scenario); err != nil {
-		panic(err)
+}
+
+func MarkBranchHandler(s *Session) game.GuiEventHandler {
+	return func(interface{}) {
+		g := s.Game
+		s.ReportError(g.Execute(g.SetCompareBranch(g.GetGameStore().CurrentBranch)))
+	}
+}
+
+// CherryPickHandler replays the decisions of the marked branch from the
+// current turn onward, a new branch is started when the player is rewinded.
+func CherryPickHandler(s *Session) game.GuiEventHandler {
+	return func(interface{}) {
+		g := s.Game
+		gameStore := g.GetGameStore()
+		source := gameStore.CompareBranch
+		if source == event.ZeroID() || source == gameStore.CurrentBranch {
+			s.ReportError(fmt.Errorf("Mark another branch to cherry pick from"))
+			return
+		}
+		report, err := g.CherryPick(source, g.GetRewindedBranchStore().Turn, gameStore.Rewind)
+		if nil != err {
+			s.ReportError(err)
+			return
+		}
+		s.Status = report.String()
+	}
+}
+
+func RenameBranchHandler(s *Session) game.GuiEventHandler {
+	return func(interface{}) {
+		g := s.Game
+		branchID := g.GetGameStore().CurrentBranch
+		s.Prompt(s.Data.Text("ui.rename_branch", "Rename Branch"), g.GetGameStore().BranchNames[branchID], func(name string) {
+			s.ReportError(g.Execute(g.SetBranchName(branchID, strings.TrimSpace(name))))
+		})
+	}
+}
+
+func BranchNoteHandler(s *Session) game.GuiEventHandler {
+	return func(interface{}) {
+		g := s.Game
+		branchID := g.GetGameStore().CurrentBranch
+		s.Prompt(s.Data.Text("ui.branch_note", "Branch Note"), g.GetGameStore().BranchNotes[branchID], func(note string) {
+			s.ReportError(g.Execute(g.SetBranchNote(branchID, strings.TrimSpace(note))))
+		})
+	}
+}
+
+func TurnNoteHandler(s *Session) game.GuiEventHandler {
+	return func(interface{}) {
+		g := s.Game
+		node := g.CurrentNode()
+		s.Prompt(s.Data.Text("ui.turn_note", "Turn Note"), g.GetGameStore().TurnNotes[node], func(note string) {
+			s.ReportError(g.Execute(g.SetTurnNote(node, strings.TrimSpace(note))))
+		})
+	}
+}
+
+// BookmarkHandler bookmarks the current turn, or removes the bookmark.
+func BookmarkHandler(s *Session) game.GuiEventHandler {
+	return func(interface{}) {
+		g := s.Game
+		node := g.CurrentNode()
+		s.ReportError(g.Execute(g.SetBookmark(node, !g.GetGameStore().Bookmarked(node))))
+	}
+}
+
+func NextBookmarkHandler(s *Session) game.GuiEventHandler {
+	return func(interface{}) {
+		node, ok := s.Game.NextBookmark()
+		if !ok {
+			s.ReportError(fmt.Errorf("There are no bookmarks"))
+			return
+		}
+		s.ReportError(s.Game.JumpTo(node))
+	}
+}
+
+// CompareBranches compares the marked branch with the current branch, the
+// parent of the current branch is used when no other branch is marked.
+func CompareBranches(s *Session) (*game.BranchDiff, error) {
+	g := s.Game
+	gameStore := g.GetGameStore()
+	other := gameStore.CompareBranch
+	if other == event.ZeroID() || other == gameStore.CurrentBranch {
+		branch, err := g.GetTimeLineStore().GetBranch(gameStore.CurrentBranch)
+		if nil != err {
+			return nil, err
+		}
+		other = branch.PrevBranch
+	}
+	if other == event.ZeroID() {
+		return nil, fmt.Errorf("Mark a branch to compare the root branch with")
+	}
+	return g.CompareBranches(other, gameStore.CurrentBranch)
+}
+
+func CompareProvider(s *Session, maxLines int) game.GuiStringProviderFunc {
+	return func() string{
+		diff, err := CompareBranches(s)
+		if nil != err {
+			return err.Error()
+		}
+		timelineStore := s.Game.GetTimeLineStore()
+		lines := []string{fmt.Sprintf("A: %s  B: %s  split from %s at turn %d",
+			game.BranchLabel(timelineStore, diff.A),
+			game.BranchLabel(timelineStore, diff.B),
+			game.BranchLabel(timelineStore, diff.CommonAncestor),
+			diff.ForkTurn,
+		)}
+		lines = append(lines, diff.Lines(s.Data)...)
+		if len(lines) > maxLines {
+			lines = append(lines[:maxLines-1], "...")
+		}
+		return strings.Join(lines, "\n")
+	}
+}
+
+func ExportCompareHandler(s *Session) game.GuiEventHandler {
+	return func(interface{}) {
+		diff, err := CompareBranches(s)
+		if nil != err {
+			s.ReportError(err)
+			return
+		}
+		fileName := filepath.Join(s.ExportDir, fmt.Sprintf("compare-%s-%s.csv", diff.A.ToString(), diff.B.ToString()))
+		if err := WriteOutput(fileName, diff.WriteCSV); nil != err {
+			s.ReportError(err)
+			return
+		}
+		s.Status = "Exported to "+fileName
 	}
-	
-	return &gameData
 }
 
 func run() {
+	config := LoadConfig()
 	cfg := pixelgl.WindowConfig{
 		Title: "Timeline",
 		Bounds: pixel.R(0, 0, 1024, 768),
@@ -167,94 +468,61 @@ func run() {
 
 	// Load Data
 	fmt.Println("Loading data")
-	gameData := LoadData()
-	gameStarted := false
-
-	// Create GUI
-	menu := game.GuiMenu{
-		Position: pixel.Vec{X: 10, Y: 768-30},
-		Bound: 20.0,
-	}
+	gameData := game.LoadData(config.DataRoots(), config.Locale)
+	game.SetGlyphs(gameData.Glyphs())
 
-	endTurnItem := game.NewGuiMenuItem("End Turn")
-	newBranchItem := game.NewGuiMenuItem("New Branch")
-	endTurnItem.OnMouseClick = EndturnHandler()
-	newBranchItem.OnMouseClick = NewBranchHandler()
+	var session *Session
 
-	menu.AddItem(endTurnItem)
-	menu.AddItem(newBranchItem)
-
-	policyList := game.GuiPolicyList{
-		Position: pixel.Vec{X: 500, Y: 768-64},
-	}
-	policyNames := []string{}
-	for k := range gameData.Policies.Policies {
-		policyNames = append(policyNames, k)
-	}
-	sort.Strings(policyNames)
-	for _, v := range policyNames {
-		policy := gameData.Policies.Policies[v]
-		guiPolicy := game.NewGuiPolicy(policy.Name)
-		guiPolicy.OnMouseClick = PolicyClickHandler(policy.Name)
-		policyList.AddPolicy(guiPolicy)
-	}
-	valueList := game.GuiPolicyList{
-		Position: pixel.Vec{X: 32, Y: 768-64},
-	}
-	valueNames := []string{}
-	for k := range gameData.Values.Values {
-		valueNames = append(valueNames, k)
+	profile, err := game.LoadProfile(config.ProfileFile)
+	if nil != err {
+		panic(err)
 	}
-	sort.Strings(valueNames)
-	for _, v := range valueNames {
-		value := gameData.Values.Values[v]
-		guiValue := game.NewGuiPolicy(value.Name)
-		guiValue.OnMouseClick = ValueClickHandler(value.Name)
-		guiValue.StringProvider = ValueStringProvider(value.Name)
-		valueList.AddPolicy(guiValue)
+	// Achievements unlocked while a save loads are recorded silently
+	onUnlock := func(id string, time uint64) {
+		unlocked, err := profile.Unlock(id, time)
+		if nil != err {
+			fmt.Println(err)
+		}
+		if unlocked && nil != session {
+			session.Notice = fmt.Sprintf("%s: %s", gameData.Text("ui.achievement_unlocked", "Achievement unlocked"), gameData.AchievementName(id))
+		}
 	}
-	guiTimeline := game.NewGuiTimeline(pixel.V(80, 768-400))
-	guiTimeline.OnMouseClick = GotoBranch()
 
-	saveGameList := game.NewSaveGameList(pixel.Vec{X: 350, Y: 768-250})
+	saveGameList := game.NewSaveGameList(pixel.Vec{X: 350, Y: 768-250}, gameData.Text("ui.overwrite", "Overwrite"))
+	total := game.Statistics{}
+	total.Add(&profile.Retired)
 	fileNameList := SaveGameFileNames()
 	for _, v := range fileNameList {
-		databaseFileName := "save/"+v+".db"
+		databaseFileName := filepath.Join(config.SaveDir, v+".db")
 		fileExists := false
 		if _, err := os.Stat(databaseFileName); !os.IsNotExist(err) {
 			fileExists = true
 		}
 		saveGameList.AddFile(v, fileExists)
+		if !fileExists {
+			continue
+		}
+		summary, err := game.ReadSaveSummary(filepath.Join(config.SaveDir, v), gameData)
+		if nil != err {
+			fmt.Println(err)
+			continue
+		}
+		if summary.Modified {
+			saveGameList.MarkFile(v, gameData.Text("ui.modified", "modified"))
+		}
+		saveGameList.SetInfo(v, summary.Statistics.Summary())
+		total.Add(&summary.Statistics)
 	}
-	saveGameList.OnMouseClick = SaveGameListClickedHandler(&gameStarted, gameData)
-
-	winText := game.NewGuiBigText("You Won :)", pixel.V(350, 600))
-	loseText := game.NewGuiBigText("You Lost :(", pixel.V(350, 600))
-
-	mainScreen := game.GuiScreen{}
-	winScreen := game.GuiScreen{}
-	loseScreen := game.GuiScreen{}
-
-	mainScreen.AddDrawable(&policyList)
-	mainScreen.AddClickable(&policyList)
-	mainScreen.AddDrawable(&valueList)
-	mainScreen.AddClickable(&valueList)
-	mainScreen.AddDrawable(guiTimeline)
-	mainScreen.AddClickable(guiTimeline)
-	mainScreen.AddClickable(&menu)
-	mainScreen.AddDrawable(&menu)
-
-	winScreen.AddDrawable(guiTimeline)
-	winScreen.AddClickable(guiTimeline)
-	winScreen.AddDrawable(winText)
-
-	loseScreen.AddDrawable(guiTimeline)
-	loseScreen.AddClickable(guiTimeline)
-	loseScreen.AddDrawable(loseText)
-
-	screens := map[string]*game.GuiScreen {
-		"main": &mainScreen,
+	totalLabel := game.NewGuiLabel(pixel.V(350, 200), StaticStringProvider(gameData.Text("ui.total", "Total")+": "+total.String()))
+	projections := func() []game.Projection {
+		return []game.Projection{game.NewAchievementProjection(gameData, onUnlock), game.NewStatisticsProjection()}
 	}
+	saveGameList.OnMouseClick = SaveGameListClickedHandler(gameData, config.SaveDir, profile, projections, func(g *game.Instance) {
+		session = NewSession(g, gameData, config.SaveDir)
+		session.Profile = profile
+		session.AutoPrune = config.AutoPrune
+		g.ForbidRewindBranching = config.ForbidRewindBranching
+	})
 
 	goLeft := false
 	goLeftTimerExp := false
@@ -262,20 +530,23 @@ func run() {
 	goRightTimerExp := false
 	for !win.Closed() {
 		win.Clear(colornames.White)
-		if gameStarted {
-			gameStore := game.Current.GetGameStore()
-			branchStore := game.Current.GetRewindedBranchStore()
-			screen := screens[gameStore.CurrentScreen]
-			if branchStore.GameOver == game.GameWon {
-				screen = &winScreen
-			}
-			if branchStore.GameOver == game.GameLost {
-				screen = &loseScreen
-			}
+		if nil != session {
+			screen := session.CurrentScreen()
 			if win.JustPressed(pixelgl.MouseButtonLeft) {
 				mousePosition := win.MousePosition()
 				screen.CheckMouse(game.LeftClick, mousePosition)
 			}
+			if nil != session.Input {
+				session.Type(
+					win.Typed(),
+					win.JustPressed(pixelgl.KeyBackspace) || win.Repeated(pixelgl.KeyBackspace),
+					win.JustPressed(pixelgl.KeyEnter),
+					win.JustPressed(pixelgl.KeyEscape),
+				)
+				screen.Draw(win, pixel.ZV)
+				win.Update()
+				continue
+			}
 			timelineDeltaX := 0.0
 			if win.JustPressed(pixelgl.KeyA){
 				timer := time.NewTimer(time.Millisecond*400)
@@ -311,7 +582,7 @@ func run() {
 				timelineDeltaX = 8.0
 			}
 
-			guiTimeline.Position.X += timelineDeltaX
+			session.Timeline.Position.X += timelineDeltaX
 			
 			screen.Draw(win, pixel.ZV)
 		} else {
@@ -320,12 +591,113 @@ func run() {
 				saveGameList.CheckMouse(game.LeftClick, mousePosition)
 			}
 			saveGameList.Draw(win, pixel.ZV)
+			totalLabel.Draw(win, pixel.ZV)
 		}
 
 		win.Update()
 	}
 }
 
+// WriteOutput calls write with the named file, - writes to stdout.
+func WriteOutput(fileName string, write func(io.Writer) error) error {
+	if fileName == "-" {
+		return write(os.Stdout)
+	}
+	file, err := os.Create(fileName)
+	if nil != err {
+		return err
+	}
+	if err := write(file); nil != err {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
 func main() {
+	flag.Parse()
+	if *dumpData {
+		config := LoadConfig()
+		if err := game.LoadData(config.DataRoots(), config.Locale).Dump(os.Stdout); nil != err {
+			panic(err)
+		}
+		return
+	}
+	if *graphDOT != "" || *graphJSON != "" {
+		config := LoadConfig()
+		graph := game.BuildGraph(game.LoadData(config.DataRoots(), config.Locale))
+		if *graphDOT != "" {
+			if err := WriteOutput(*graphDOT, graph.WriteDOT); nil != err {
+				panic(err)
+			}
+		}
+		if *graphJSON != "" {
+			if err := WriteOutput(*graphJSON, graph.WriteJSON); nil != err {
+				panic(err)
+			}
+		}
+		return
+	}
+	if *compact != "" {
+		config := LoadConfig()
+		gameData := game.LoadData(config.DataRoots(), config.Locale)
+		dropped, err := game.CompactSave(filepath.Join(config.SaveDir, *compact), gameData)
+		if nil != err {
+			panic(err)
+		}
+		fmt.Println(fmt.Sprintf("Dropped %d events", dropped))
+		return
+	}
+	if *exportLog != "" {
+		config := LoadConfig()
+		gameData := game.LoadData(config.DataRoots(), config.Locale)
+		fileName := filepath.Join(config.SaveDir, *exportLog)
+		if _, err := os.Stat(fileName+".db"); os.IsNotExist(err) {
+			fmt.Println(fmt.Sprintf("Save %s doesn't exist", *exportLog))
+			os.Exit(1)
+		}
+		g, err := game.NewGame(fileName, gameData)
+		if nil != err {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		err = WriteOutput(*logFile, g.ExportEventLog)
+		g.Close()
+		if nil != err {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
+	if *importLog != "" {
+		config := LoadConfig()
+		gameData := game.LoadData(config.DataRoots(), config.Locale)
+		input := os.Stdin
+		if *logFile != "-" {
+			file, err := os.Open(*logFile)
+			if nil != err {
+				panic(err)
+			}
+			defer file.Close()
+			input = file
+		}
+		os.MkdirAll(config.SaveDir, os.ModePerm)
+		if err := game.ImportEventLog(filepath.Join(config.SaveDir, *importLog), gameData, input); nil != err {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		fmt.Println(fmt.Sprintf("Imported into %s", *importLog))
+		return
+	}
+	if *checkSnapshot != "" {
+		config := LoadConfig()
+		gameData := game.LoadData(config.DataRoots(), config.Locale)
+		if err := game.CheckSnapshot(filepath.Join(config.SaveDir, *checkSnapshot), gameData); nil != err {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		fmt.Println("Snapshot matches the event log")
+		return
+	}
 	pixelgl.Run(run)
 }
\ No newline at end of file
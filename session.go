@@ -0,0 +1,343 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/faiface/pixel"
+
+	"github.com/pkartner/timeline/game"
+)
+
+// Session is a loaded game together with the screens showing it.
+type Session struct {
+	Game *game.Instance
+	Data *game.Data
+	Screens map[string]*game.GuiScreen
+	WinScreen *game.GuiScreen
+	LoseScreen *game.GuiScreen
+	TimelineWinScreen *game.GuiScreen
+	TimelineLoseScreen *game.GuiScreen
+	Timeline *game.GuiTimeLine
+	ExportDir string
+	// Status holds the reason the last player action was rejected.
+	Status string
+	// Input is the text the player is typing, nil when not typing.
+	Input *TextInput
+	// AutoPrune archives lost branches once the player leaves them.
+	AutoPrune bool
+	Profile *game.Profile
+	// Notice holds the last achievement unlocked.
+	Notice string
+}
+
+// TextInput collects typed text until enter is pressed, Done is called with
+// the text then.
+type TextInput struct {
+	Prompt string
+	Text string
+	Done func(string)
+}
+
+// Prompt starts asking the player for text, starting with the current text.
+func (s *Session) Prompt(prompt string, current string, done func(string)) {
+	s.Input = &TextInput{
+		Prompt: prompt,
+		Text: current,
+		Done: done,
+	}
+}
+
+// Confirm asks the player a yes or no question, yes is called when the
+// answer starts with a y.
+func (s *Session) Confirm(question string, yes func()) {
+	s.Prompt(question+" (y/n)", "", func(answer string) {
+		if strings.HasPrefix(strings.ToLower(strings.TrimSpace(answer)), "y") {
+			yes()
+		}
+	})
+}
+
+// Type handles the keys typed while the player is asking for text.
+func (s *Session) Type(typed string, backspace bool, enter bool, escape bool) {
+	input := s.Input
+	if nil == input {
+		return
+	}
+	input.Text += typed
+	if backspace && len(input.Text) > 0 {
+		runes := []rune(input.Text)
+		input.Text = string(runes[:len(runes)-1])
+	}
+	if escape {
+		s.Input = nil
+		return
+	}
+	if enter {
+		s.Input = nil
+		input.Done(input.Text)
+	}
+}
+
+func (s *Session) ReportError(err error) {
+	s.Status = ""
+	if nil != err {
+		fmt.Println(err)
+		s.Status = err.Error()
+	}
+}
+
+// CurrentScreen returns the screen to draw for the current state of the game.
+func (s *Session) CurrentScreen() *game.GuiScreen {
+	gameStore := s.Game.GetGameStore()
+	branchStore := s.Game.GetRewindedBranchStore()
+	screen := s.Screens[gameStore.CurrentScreen]
+	if branchStore.GameOver == game.GameWon {
+		screen = s.WinScreen
+	}
+	if branchStore.GameOver == game.GameLost {
+		screen = s.LoseScreen
+	}
+	if gameStore.Timeline == game.GameWon {
+		screen = s.TimelineWinScreen
+	}
+	if gameStore.Timeline == game.GameLost {
+		screen = s.TimelineLoseScreen
+	}
+	return screen
+}
+
+func NewSession(g *game.Instance, gameData *game.Data, exportDir string) *Session {
+	s := &Session{
+		Game: g,
+		Data: gameData,
+		ExportDir: exportDir,
+	}
+
+	menu := game.GuiMenu{
+		Position: pixel.Vec{X: 10, Y: 768-30},
+		Bound: 20.0,
+	}
+
+	endTurnItem := game.NewGuiMenuItem(gameData.Text("ui.end_turn", "End Turn"))
+	newBranchItem := game.NewGuiMenuItem(gameData.Text("ui.new_branch", "New Branch"))
+	endTurnItem.OnMouseClick = EndturnHandler(s)
+	newBranchItem.OnMouseClick = NewBranchHandler(s)
+
+	markBranchItem := game.NewGuiMenuItem(gameData.Text("ui.mark_branch", "Mark Branch"))
+	compareItem := game.NewGuiMenuItem(gameData.Text("ui.compare", "Compare"))
+	markBranchItem.OnMouseClick = MarkBranchHandler(s)
+	compareItem.OnMouseClick = MenuButtonHandler(s, "compare")
+	cherryPickItem := game.NewGuiMenuItem(gameData.Text("ui.cherry_pick", "Cherry Pick"))
+	cherryPickItem.OnMouseClick = CherryPickHandler(s)
+
+	branchesItem := game.NewGuiMenuItem(gameData.Text("ui.branches", "Branches"))
+	branchesItem.OnMouseClick = MenuButtonHandler(s, "branches")
+
+	menu.AddItem(endTurnItem)
+	menu.AddItem(newBranchItem)
+	menu.AddItem(markBranchItem)
+	menu.AddItem(compareItem)
+	menu.AddItem(cherryPickItem)
+	menu.AddItem(branchesItem)
+
+	// Managing branches, notes and bookmarks doesn't fit the top row
+	branchesMenu := game.GuiMenu{
+		Position: pixel.Vec{X: 10, Y: 768-30},
+		Bound: 10.0,
+		Vertical: true,
+	}
+	branchesBackItem := game.NewGuiMenuItem(gameData.Text("ui.back", "Back"))
+	branchesBackItem.OnMouseClick = MenuButtonHandler(s, "main")
+	branchesMenu.AddItem(branchesBackItem)
+
+	renameItem := game.NewGuiMenuItem(gameData.Text("ui.rename_branch", "Rename Branch"))
+	renameItem.OnMouseClick = RenameBranchHandler(s)
+	branchNoteItem := game.NewGuiMenuItem(gameData.Text("ui.branch_note", "Branch Note"))
+	branchNoteItem.OnMouseClick = BranchNoteHandler(s)
+	turnNoteItem := game.NewGuiMenuItem(gameData.Text("ui.turn_note", "Turn Note"))
+	turnNoteItem.OnMouseClick = TurnNoteHandler(s)
+	bookmarkItem := game.NewGuiMenuItem(gameData.Text("ui.bookmark", "Bookmark"))
+	bookmarkItem.OnMouseClick = BookmarkHandler(s)
+	nextBookmarkItem := game.NewGuiMenuItem(gameData.Text("ui.next_bookmark", "Next Bookmark"))
+	nextBookmarkItem.OnMouseClick = NextBookmarkHandler(s)
+	branchesMenu.AddItem(renameItem)
+	branchesMenu.AddItem(branchNoteItem)
+	branchesMenu.AddItem(turnNoteItem)
+	branchesMenu.AddItem(bookmarkItem)
+	branchesMenu.AddItem(nextBookmarkItem)
+
+	archiveItem := game.NewGuiMenuItem(gameData.Text("ui.archive_branch", "Archive Marked"))
+	archiveItem.OnMouseClick = ArchiveBranchHandler(s)
+	deleteItem := game.NewGuiMenuItem(gameData.Text("ui.delete_branch", "Delete Marked"))
+	deleteItem.OnMouseClick = DeleteBranchHandler(s)
+	showArchivedItem := game.NewGuiMenuItem(gameData.Text("ui.show_archived", "Show Archived"))
+	showArchivedItem.OnMouseClick = ShowArchivedHandler(s)
+	branchesMenu.AddItem(archiveItem)
+	branchesMenu.AddItem(deleteItem)
+	branchesMenu.AddItem(showArchivedItem)
+	achievementsItem := game.NewGuiMenuItem(gameData.Text("ui.achievements", "Achievements"))
+	achievementsItem.OnMouseClick = MenuButtonHandler(s, "achievements")
+	branchesMenu.AddItem(achievementsItem)
+
+	policyList := game.GuiPolicyList{
+		Position: pixel.Vec{X: 500, Y: 768-64},
+	}
+	policyNames := []string{}
+	for k := range gameData.Policies.Policies {
+		if !gameData.Scenario.PolicyAvailable(k) {
+			continue
+		}
+		policyNames = append(policyNames, k)
+	}
+	sort.Strings(policyNames)
+	for _, v := range policyNames {
+		guiPolicy := game.NewGuiPolicy(g, v, gameData.PolicyName(v))
+		guiPolicy.OnMouseClick = PolicyClickHandler(s, v)
+		policyList.AddPolicy(guiPolicy)
+	}
+	valueList := game.GuiPolicyList{
+		Position: pixel.Vec{X: 32, Y: 768-64},
+	}
+	valueNames := []string{}
+	for k := range gameData.Values.Values {
+		valueNames = append(valueNames, k)
+	}
+	sort.Strings(valueNames)
+	for _, v := range valueNames {
+		guiValue := game.NewGuiPolicy(g, v, gameData.ValueName(v))
+		guiValue.OnMouseClick = ValueClickHandler(s, v)
+		guiValue.StringProvider = ValueStringProvider(s, v)
+		valueList.AddPolicy(guiValue)
+	}
+	valueDescription := game.NewGuiLabel(pixel.V(32, 40), SelectedValueDescriptionProvider(s))
+	s.Timeline = game.NewGuiTimeline(g, pixel.V(80, 768-400))
+	s.Timeline.OnMouseClick = GotoBranch(s)
+
+	winText := game.NewGuiBigText(gameData.Text("ui.won", "You Won :)"), pixel.V(350, 600))
+	loseText := game.NewGuiBigText(gameData.Text("ui.lost", "You Lost :("), pixel.V(350, 600))
+	timelineWinText := game.NewGuiBigText(gameData.Text("ui.timeline_won", "The timeline is saved :)"), pixel.V(300, 600))
+	timelineLoseText := game.NewGuiBigText(gameData.Text("ui.timeline_lost", "The timeline collapsed :("), pixel.V(300, 600))
+
+	storyMessages := game.NewGuiLabel(pixel.V(500, 520), StoryMessagesProvider(s, 3))
+	objectives := strings.Join(gameData.Objectives(), "\n")
+	statusLabel := game.NewGuiLabel(pixel.V(32, 80), StatusMessageProvider(s))
+	notesLabel := game.NewGuiLabel(pixel.V(32, 140), NotesProvider(s))
+	objectivesLabel := game.NewGuiLabel(pixel.V(500, 100), StaticStringProvider(objectives))
+
+	introMenu := game.GuiMenu{
+		Position: pixel.Vec{X: 350, Y: 100},
+		Bound: 20.0,
+	}
+	startItem := game.NewGuiMenuItem(gameData.Text("ui.start", "Start"))
+	startItem.OnMouseClick = MenuButtonHandler(s, "main")
+	introMenu.AddItem(startItem)
+	introTitle := game.NewGuiBigText(gameData.IntroTitle(), pixel.V(100, 650))
+	introText := game.NewGuiLabel(pixel.V(100, 560), StaticStringProvider(game.WrapText(gameData.IntroText(), 80)+"\n\n"+objectives))
+
+	introScreen := game.GuiScreen{}
+	introScreen.AddDrawable(introTitle)
+	introScreen.AddDrawable(introText)
+	introScreen.AddDrawable(&introMenu)
+	introScreen.AddClickable(&introMenu)
+
+	compareMenu := game.GuiMenu{
+		Position: pixel.Vec{X: 10, Y: 768-30},
+		Bound: 20.0,
+	}
+	backItem := game.NewGuiMenuItem(gameData.Text("ui.back", "Back"))
+	backItem.OnMouseClick = MenuButtonHandler(s, "main")
+	exportItem := game.NewGuiMenuItem(gameData.Text("ui.export_csv", "Export CSV"))
+	exportItem.OnMouseClick = ExportCompareHandler(s)
+	compareMenu.AddItem(backItem)
+	compareMenu.AddItem(exportItem)
+	compareText := game.NewGuiLabel(pixel.V(32, 768-80), CompareProvider(s, 30))
+
+	compareScreen := game.GuiScreen{}
+	compareScreen.AddDrawable(&compareMenu)
+	compareScreen.AddClickable(&compareMenu)
+	compareScreen.AddDrawable(compareText)
+	compareScreen.AddDrawable(statusLabel)
+
+	achievementsMenu := game.GuiMenu{
+		Position: pixel.Vec{X: 10, Y: 768-30},
+		Bound: 20.0,
+	}
+	achievementsBackItem := game.NewGuiMenuItem(gameData.Text("ui.back", "Back"))
+	achievementsBackItem.OnMouseClick = MenuButtonHandler(s, "main")
+	achievementsMenu.AddItem(achievementsBackItem)
+	achievementsText := game.NewGuiLabel(pixel.V(32, 768-80), AchievementsProvider(s))
+
+	branchesScreen := game.GuiScreen{}
+	branchesScreen.AddDrawable(&branchesMenu)
+	branchesScreen.AddClickable(&branchesMenu)
+	branchesScreen.AddDrawable(statusLabel)
+	branchesScreen.AddDrawable(notesLabel)
+
+	achievementsScreen := game.GuiScreen{}
+	achievementsScreen.AddDrawable(&achievementsMenu)
+	achievementsScreen.AddClickable(&achievementsMenu)
+	achievementsScreen.AddDrawable(achievementsText)
+
+	noticeLabel := game.NewGuiLabel(pixel.V(500, 40), NoticeProvider(s))
+	energyLabel := game.NewGuiLabel(pixel.V(32, 110), TemporalEnergyProvider(s))
+	discoveriesLabel := game.NewGuiLabel(pixel.V(500, 300), DiscoveriesProvider(s, 3))
+
+	mainScreen := game.GuiScreen{}
+	winScreen := game.GuiScreen{}
+	loseScreen := game.GuiScreen{}
+
+	mainScreen.AddDrawable(&policyList)
+	mainScreen.AddClickable(&policyList)
+	mainScreen.AddDrawable(&valueList)
+	mainScreen.AddClickable(&valueList)
+	mainScreen.AddDrawable(valueDescription)
+	mainScreen.AddDrawable(storyMessages)
+	mainScreen.AddDrawable(objectivesLabel)
+	mainScreen.AddDrawable(statusLabel)
+	mainScreen.AddDrawable(notesLabel)
+	mainScreen.AddDrawable(noticeLabel)
+	mainScreen.AddDrawable(energyLabel)
+	mainScreen.AddDrawable(discoveriesLabel)
+	mainScreen.AddDrawable(s.Timeline)
+	mainScreen.AddClickable(s.Timeline)
+	mainScreen.AddClickable(&menu)
+	mainScreen.AddDrawable(&menu)
+
+	winScreen.AddDrawable(s.Timeline)
+	winScreen.AddClickable(s.Timeline)
+	winScreen.AddDrawable(winText)
+	winScreen.AddDrawable(noticeLabel)
+
+	loseScreen.AddDrawable(s.Timeline)
+	loseScreen.AddClickable(s.Timeline)
+	loseScreen.AddDrawable(loseText)
+	loseScreen.AddDrawable(noticeLabel)
+
+	timelineWinScreen := game.GuiScreen{}
+	timelineWinScreen.AddDrawable(s.Timeline)
+	timelineWinScreen.AddClickable(s.Timeline)
+	timelineWinScreen.AddDrawable(timelineWinText)
+	timelineWinScreen.AddDrawable(noticeLabel)
+
+	timelineLoseScreen := game.GuiScreen{}
+	timelineLoseScreen.AddDrawable(s.Timeline)
+	timelineLoseScreen.AddClickable(s.Timeline)
+	timelineLoseScreen.AddDrawable(timelineLoseText)
+	timelineLoseScreen.AddDrawable(noticeLabel)
+
+	s.Screens = map[string]*game.GuiScreen {
+		"main": &mainScreen,
+		"intro": &introScreen,
+		"compare": &compareScreen,
+		"achievements": &achievementsScreen,
+		"branches": &branchesScreen,
+	}
+	s.WinScreen = &winScreen
+	s.LoseScreen = &loseScreen
+	s.TimelineWinScreen = &timelineWinScreen
+	s.TimelineLoseScreen = &timelineLoseScreen
+
+	return s
+}